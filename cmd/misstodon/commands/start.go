@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	_ "embed"
 	"fmt"
 	"net/http"
@@ -9,7 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gizmo-ds/misstodon/internal/api"
 	"github.com/gizmo-ds/misstodon/internal/global"
+	"github.com/gizmo-ds/misstodon/internal/storage"
 	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
 	"github.com/rs/zerolog/log"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/acme/autocert"
@@ -48,6 +51,20 @@ var Start = &cli.Command{
 		}
 		bindAddress, _ := utils.StrEvaluation(c.String("bind"), conf.Server.BindAddress)
 
+		if err := storage.Init(conf.Database.Type, conf.Database.Address); err != nil {
+			return err
+		}
+		defer storage.Default.Close()
+		log.Info().Str("type", conf.Database.Type).Str("address", conf.Database.Address).Msg("Storage opened")
+
+		schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+		defer stopScheduler()
+		go func() {
+			if err := misskey.RunScheduler(schedulerCtx); err != nil {
+				log.Error().Err(err).Msg("Failed to start the scheduler of scheduled statuses")
+			}
+		}()
+
 		gin.SetMode(gin.ReleaseMode)
 		r := gin.New()
 
@@ -3,11 +3,14 @@ package main
 import (
 	_ "embed"
 	"os"
+	"time"
 
 	"github.com/gizmo-ds/misstodon/cmd/misstodon/commands"
 	"github.com/gizmo-ds/misstodon/cmd/misstodon/logger"
 	"github.com/gizmo-ds/misstodon/internal/global"
+	"github.com/gizmo-ds/misstodon/internal/utils"
 	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/gizmo-ds/misstodon/proxy/misskey/streaming"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -41,6 +44,17 @@ func main() {
 			}
 			logger.Init(c.Bool("no-color"))
 			misskey.SetHeader("User-Agent", "misstodon/"+global.AppVersion)
+			utils.SetInsecureUpstreams(global.Config.Proxy.InsecureUpstreams)
+			if global.Config.Markers.Backend != "" {
+				misskey.MarkersBackend = global.Config.Markers.Backend
+			}
+			if global.Config.Statuses.ContextLimit > 0 {
+				misskey.StatusContextLimit = global.Config.Statuses.ContextLimit
+			}
+			streaming.DefaultHub.PollingFallback = global.Config.Streaming.PollingFallback
+			if global.Config.Streaming.PollInterval > 0 {
+				streaming.DefaultHub.PollInterval = time.Duration(global.Config.Streaming.PollInterval) * time.Second
+			}
 			return nil
 		},
 		Commands: []*cli.Command{
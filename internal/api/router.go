@@ -44,10 +44,16 @@ func Router(r *gin.Engine) {
 		v1.MutesRouter(v1Api)
 		v1.ReportsRouter(v1Api)
 		v1.AnnouncementsRouter(v1Api)
+		v1.ListsRouter(v1Api)
+		v1.FiltersRouter(v1Api)
+		v1.ScheduledStatusesRouter(v1Api)
+		v1.ConversationsRouter(v1Api)
+		v1.ReactionsRouter(v1Api)
 		v2.MediaRouter(v2Api)
 		v2.SearchRouter(v2Api)
 		v2.InstanceRouter(v2Api)
 		v2.SuggestionsRouter(v2Api)
+		v2.FiltersRouter(v2Api)
 
 		v1Api.GET("/bookmarks", v1.StatusBookmarks)
 		v1Api.GET("/follow_requests", v1.AccountFollowRequests)
@@ -57,14 +63,9 @@ func Router(r *gin.Engine) {
 		v1Api.GET("/preferences", v1.PreferencesHandler)
 		v1Api.GET("/markers", v1.MarkersGetHandler)
 		v1Api.POST("/markers", v1.MarkersPostHandler)
-		v1Api.GET("/conversations", v1.ConversationsHandler)
 		v1Api.GET("/followed_tags", func(c *gin.Context) { c.JSON(200, []any{}) })
 		v1Api.GET("/endorsements", func(c *gin.Context) { c.JSON(200, []any{}) })
-		v1Api.GET("/lists", func(c *gin.Context) { c.JSON(200, []any{}) })
 		v1Api.GET("/domain_blocks", func(c *gin.Context) { c.JSON(200, []any{}) })
-		v1Api.GET("/filters", func(c *gin.Context) { c.JSON(200, []any{}) })
 		v1Api.GET("/featured_tags", func(c *gin.Context) { c.JSON(200, []any{}) })
-		v1Api.GET("/scheduled_statuses", func(c *gin.Context) { c.JSON(200, []any{}) })
-		v2Api.GET("/filters", func(c *gin.Context) { c.JSON(200, []any{}) })
 	}
 }
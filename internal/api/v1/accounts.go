@@ -32,7 +32,7 @@ func AccountsRouter(r *gin.RouterGroup) {
 	group.POST("/:id/unmute", AccountUnmute)
 	group.POST("/:id/block", AccountBlock)
 	group.POST("/:id/unblock", AccountUnblock)
-	group.GET("/:id/lists", func(c *gin.Context) { c.JSON(200, []any{}) })
+	group.GET("/:id/lists", AccountLists)
 	group.GET("/:id/featured_tags", func(c *gin.Context) { c.JSON(200, []any{}) })
 }
 
@@ -85,7 +85,7 @@ func AccountsLookupHandler(c *gin.Context) {
 func AccountsStatusesHandler(c *gin.Context) {
 	uid := c.Param("id")
 
-	ctx, _ := misstodon.ContextWithGinContext(c)
+	ctx := misstodon.ContextWithOptionalToken(c)
 
 	limit := 30
 	pinnedOnly := false
@@ -138,6 +138,7 @@ func AccountsStatusesHandler(c *gin.Context) {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	statuses = misskey.FiltersApply(ctx, models.FilterContextAccount, statuses)
 	c.JSON(http.StatusOK, utils.SliceIfNull(statuses))
 }
 
@@ -545,3 +546,17 @@ func AccountUnblock(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, relationships[0])
 }
+
+func AccountLists(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	lists, err := misskey.AccountLists(ctx, c.Param("id"))
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(lists))
+}
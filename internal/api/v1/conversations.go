@@ -4,8 +4,85 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
 )
 
+func ConversationsRouter(r *gin.RouterGroup) {
+	group := r.Group("/conversations")
+	group.GET("", ConversationsHandler)
+	group.POST("/:id/read", ConversationReadHandler)
+	group.DELETE("/:id", ConversationDeleteHandler)
+}
+
+func abortWithConversationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+		return
+	case errors.Is(err, misskey.ErrUnauthorized):
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	httperror.AbortWithError(c, http.StatusInternalServerError, err)
+}
+
 func ConversationsHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, []any{})
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var query struct {
+		Limit   int    `form:"limit"`
+		MaxID   string `form:"max_id"`
+		SinceID string `form:"since_id"`
+		MinID   string `form:"min_id"`
+	}
+	if err = c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+	if query.Limit > 40 {
+		query.Limit = 40
+	}
+	conversations, err := misskey.Conversations(ctx, query.Limit, query.MaxID, query.SinceID, query.MinID)
+	if err != nil {
+		abortWithConversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(conversations))
+}
+
+func ConversationReadHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	conversation, err := misskey.ConversationRead(ctx, c.Param("id"))
+	if err != nil {
+		abortWithConversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conversation)
+}
+
+func ConversationDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err = misskey.ConversationDelete(ctx, c.Param("id")); err != nil {
+		abortWithConversationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
 }
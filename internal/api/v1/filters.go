@@ -0,0 +1,229 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
+)
+
+// The v1 filters are the keywords of the v2 filters, a v1 filter is created as a v2 filter with a single keyword.
+
+func FiltersRouter(r *gin.RouterGroup) {
+	group := r.Group("/filters")
+	group.GET("", FiltersHandler)
+	group.POST("", FilterCreateHandler)
+	group.GET("/:id", FilterHandler)
+	group.PUT("/:id", FilterUpdateHandler)
+	group.DELETE("/:id", FilterDeleteHandler)
+}
+
+func abortWithFilterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+	case errors.Is(err, misskey.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, httperror.ServerError{Error: err.Error()})
+	default:
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+	}
+}
+
+type filterV1Form struct {
+	Phrase       *string                `json:"phrase"`
+	Context      []models.FilterContext `json:"context"`
+	Irreversible *bool                  `json:"irreversible"`
+	WholeWord    *bool                  `json:"whole_word"`
+	ExpiresIn    any                    `json:"expires_in"`
+}
+
+func bindFilterV1Form(c *gin.Context) (form filterV1Form, err error) {
+	if strings.HasPrefix(c.ContentType(), binding.MIMEJSON) {
+		err = c.ShouldBindJSON(&form)
+		return
+	}
+	if v, ok := c.GetPostForm("phrase"); ok {
+		form.Phrase = &v
+	}
+	if v, ok := c.GetPostFormArray("context[]"); ok {
+		form.Context = v
+	}
+	if v, ok := c.GetPostForm("irreversible"); ok {
+		irreversible := v == "1" || strings.EqualFold(v, "true")
+		form.Irreversible = &irreversible
+	}
+	if v, ok := c.GetPostForm("whole_word"); ok {
+		wholeWord := v == "1" || strings.EqualFold(v, "true")
+		form.WholeWord = &wholeWord
+	}
+	if v, ok := c.GetPostForm("expires_in"); ok {
+		form.ExpiresIn = v
+	}
+	return
+}
+
+// apply updates the filter and its keyword with the parameters of the form.
+func (form filterV1Form) apply(f *models.Filter, k *models.FilterKeyword) {
+	if form.Phrase != nil {
+		k.Keyword = *form.Phrase
+	}
+	if form.WholeWord != nil {
+		k.WholeWord = *form.WholeWord
+	}
+	if form.Context != nil {
+		f.Context = form.Context
+	}
+	if form.Irreversible != nil {
+		f.FilterAction = models.FilterActionWarn
+		if *form.Irreversible {
+			f.FilterAction = models.FilterActionHide
+		}
+	}
+	switch v := form.ExpiresIn.(type) {
+	case float64:
+		f.SetExpiresIn(int(v))
+	case string:
+		seconds, _ := strconv.Atoi(v)
+		f.SetExpiresIn(seconds)
+	}
+}
+
+func FiltersHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filters, err := misskey.Filters(ctx)
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	var result []models.FilterV1
+	for _, f := range filters {
+		for _, k := range f.Keywords {
+			result = append(result, f.ToFilterV1(k))
+		}
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(result))
+}
+
+func FilterHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.ToFilterV1(keyword))
+}
+
+func FilterCreateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := bindFilterV1Form(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if form.Phrase == nil || *form.Phrase == "" {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Keyword can't be blank"})
+		return
+	}
+	filter := models.Filter{Title: *form.Phrase, FilterAction: models.FilterActionWarn}
+	var keyword models.FilterKeyword
+	form.apply(&filter, &keyword)
+	if err = filter.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	filter.Keywords = []models.FilterKeyword{keyword}
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.ToFilterV1(filter.Keywords[0]))
+}
+
+func FilterUpdateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := bindFilterV1Form(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	filter, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	form.apply(&filter, &keyword)
+	if keyword.Keyword == "" {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Keyword can't be blank"})
+		return
+	}
+	if err = filter.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	for i := range filter.Keywords {
+		if filter.Keywords[i].ID == keyword.ID {
+			filter.Keywords[i] = keyword
+		}
+	}
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.ToFilterV1(keyword))
+}
+
+func FilterDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	var keywords []models.FilterKeyword
+	for _, k := range filter.Keywords {
+		if k.ID != keyword.ID {
+			keywords = append(keywords, k)
+		}
+	}
+	filter.Keywords = keywords
+	if len(filter.Keywords) == 0 && len(filter.Statuses) == 0 {
+		// The filter was created through the v1 API and has nothing left.
+		err = misskey.FilterDelete(ctx, filter.ID)
+	} else {
+		err = misskey.FilterSave(ctx, &filter)
+	}
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
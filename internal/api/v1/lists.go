@@ -0,0 +1,208 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
+)
+
+func ListsRouter(r *gin.RouterGroup) {
+	group := r.Group("/lists")
+	group.GET("", ListsHandler)
+	group.POST("", ListCreateHandler)
+	group.GET("/:id", ListHandler)
+	group.PUT("/:id", ListUpdateHandler)
+	group.DELETE("/:id", ListDeleteHandler)
+	group.GET("/:id/accounts", ListAccountsHandler)
+	group.POST("/:id/accounts", ListAccountsAddHandler)
+	group.DELETE("/:id/accounts", ListAccountsRemoveHandler)
+}
+
+// abortWithListError responds 404 if the list does not exist, and 422 if it is backed by an antenna or a channel.
+func abortWithListError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+		return
+	case errors.Is(err, misskey.ErrListReadOnly):
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "This list is read-only"})
+		return
+	}
+	httperror.AbortWithError(c, http.StatusInternalServerError, err)
+}
+
+func ListsHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	lists, err := misskey.Lists(ctx)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(lists))
+}
+
+func ListHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	list, err := misskey.ListGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+type listForm struct {
+	Title string `json:"title" form:"title"`
+}
+
+func ListCreateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form listForm
+	if err = c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if form.Title == "" {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Title can't be blank"})
+		return
+	}
+	list, err := misskey.ListCreate(ctx, form.Title)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func ListUpdateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form listForm
+	if err = c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	var list models.List
+	if form.Title == "" {
+		// replies_policy and exclusive have no Misskey equivalent, so only the title can change.
+		list, err = misskey.ListGet(ctx, c.Param("id"))
+	} else {
+		list, err = misskey.ListUpdate(ctx, c.Param("id"), form.Title)
+	}
+	if err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func ListDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err = misskey.ListDelete(ctx, c.Param("id")); err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func ListAccountsHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	limit := 40
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
+		// limit=0 returns all the accounts of the list.
+		limit = v
+	}
+	accounts, err := misskey.ListAccounts(ctx, c.Param("id"), limit, c.Query("max_id"), c.Query("since_id"))
+	if err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(accounts))
+}
+
+// listAccountIDs returns the account_ids of the request, from a JSON body or form values.
+func listAccountIDs(c *gin.Context) ([]string, error) {
+	if strings.HasPrefix(c.ContentType(), binding.MIMEJSON) {
+		var form struct {
+			AccountIDs []string `json:"account_ids"`
+		}
+		if err := c.ShouldBindJSON(&form); err != nil {
+			return nil, err
+		}
+		return form.AccountIDs, nil
+	}
+	var ids []string
+	for _, key := range []string{"account_ids[]", "account_ids"} {
+		ids = append(ids, c.QueryArray(key)...)
+		ids = append(ids, c.PostFormArray(key)...)
+	}
+	return ids, nil
+}
+
+func ListAccountsAddHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	ids, err := listAccountIDs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if err = misskey.ListAccountsAdd(ctx, c.Param("id"), ids); err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func ListAccountsRemoveHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	ids, err := listAccountIDs(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if err = misskey.ListAccountsRemove(ctx, c.Param("id"), ids); err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
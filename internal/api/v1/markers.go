@@ -2,14 +2,69 @@ package v1
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
 )
 
 func MarkersGetHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{})
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	timelines := append(c.QueryArray("timeline[]"), c.QueryArray("timeline")...)
+	if len(timelines) == 0 {
+		timelines = misskey.MarkerTimelines
+	}
+	markers, err := misskey.MarkersGet(ctx, timelines)
+	if err != nil {
+		if errors.Is(err, misskey.ErrUnauthorized) {
+			httperror.AbortWithError(c, http.StatusUnauthorized, err)
+			return
+		}
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, markers)
 }
 
 func MarkersPostHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{})
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	lastReadIDs := make(map[string]string)
+	if strings.HasPrefix(c.ContentType(), binding.MIMEJSON) {
+		var form map[string]struct {
+			LastReadID string `json:"last_read_id"`
+		}
+		if err := c.ShouldBindJSON(&form); err != nil {
+			c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+			return
+		}
+		for timeline, marker := range form {
+			lastReadIDs[timeline] = marker.LastReadID
+		}
+	} else {
+		for _, timeline := range misskey.MarkerTimelines {
+			lastReadIDs[timeline] = c.PostForm(timeline + "[last_read_id]")
+		}
+	}
+	markers, err := misskey.MarkersSet(ctx, lastReadIDs)
+	if err != nil {
+		if errors.Is(err, misskey.ErrUnauthorized) {
+			httperror.AbortWithError(c, http.StatusUnauthorized, err)
+			return
+		}
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, markers)
 }
@@ -108,5 +108,6 @@ func NotificationsHandler(c *gin.Context) {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	result = misskey.FiltersApplyNotifications(ctx, result)
 	c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,76 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
+)
+
+// ReactionsRouter registers the emoji reaction routes of Pleroma and Fedibird.
+func ReactionsRouter(r *gin.RouterGroup) {
+	pleroma := r.Group("/pleroma/statuses/:id/reactions")
+	pleroma.GET("", StatusReactionsHandler)
+	pleroma.GET("/:emoji", StatusReactionsHandler)
+	pleroma.PUT("/:emoji", StatusReactHandler)
+	pleroma.DELETE("/:emoji", StatusUnreactHandler)
+
+	fedibird := r.Group("/statuses/:id/emoji_reactions")
+	fedibird.PUT("/:emoji", StatusReactHandler)
+	fedibird.DELETE("/:emoji", StatusUnreactHandler)
+}
+
+func abortWithReactionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+	case errors.Is(err, misskey.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, httperror.ServerError{Error: err.Error()})
+	case errors.Is(err, misskey.ErrReactionRemote):
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+	default:
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+	}
+}
+
+func StatusReactionsHandler(c *gin.Context) {
+	ctx := misstodon.ContextWithOptionalToken(c)
+	reactions, err := misskey.StatusReactions(ctx, c.Param("id"), c.Param("emoji"))
+	if err != nil {
+		abortWithReactionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(reactions))
+}
+
+func StatusReactHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	status, err := misskey.StatusReact(ctx, c.Param("id"), c.Param("emoji"))
+	if err != nil {
+		abortWithReactionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func StatusUnreactHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	status, err := misskey.StatusUnreact(ctx, c.Param("id"), c.Param("emoji"))
+	if err != nil {
+		abortWithReactionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
+)
+
+func ScheduledStatusesRouter(r *gin.RouterGroup) {
+	group := r.Group("/scheduled_statuses")
+	group.GET("", ScheduledStatusesHandler)
+	group.GET("/:id", ScheduledStatusHandler)
+	group.PUT("/:id", ScheduledStatusUpdateHandler)
+	group.DELETE("/:id", ScheduledStatusDeleteHandler)
+}
+
+func abortWithScheduledStatusError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+		return
+	case errors.Is(err, misskey.ErrScheduledAtTooSoon):
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		return
+	case errors.Is(err, misskey.ErrUnauthorized):
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	httperror.AbortWithError(c, http.StatusInternalServerError, err)
+}
+
+func ScheduledStatusesHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var query struct {
+		Limit   int    `form:"limit"`
+		MaxID   string `form:"max_id"`
+		SinceID string `form:"since_id"`
+		MinID   string `form:"min_id"`
+	}
+	if err = c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if query.Limit <= 0 {
+		query.Limit = 20
+	}
+	if query.Limit > 40 {
+		query.Limit = 40
+	}
+	statuses, err := misskey.ScheduledStatuses(ctx, query.Limit, query.MaxID, query.SinceID, query.MinID)
+	if err != nil {
+		abortWithScheduledStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(statuses))
+}
+
+func ScheduledStatusHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	status, err := misskey.ScheduledStatusGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithScheduledStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func ScheduledStatusUpdateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form struct {
+		ScheduledAt time.Time `json:"scheduled_at"`
+	}
+	if strings.HasPrefix(c.ContentType(), binding.MIMEJSON) {
+		err = c.ShouldBindJSON(&form)
+	} else if v := c.PostForm("scheduled_at"); v != "" {
+		form.ScheduledAt, err = time.Parse(time.RFC3339, v)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if form.ScheduledAt.IsZero() {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Scheduled at can't be blank"})
+		return
+	}
+	status, err := misskey.ScheduledStatusUpdate(ctx, c.Param("id"), form.ScheduledAt)
+	if err != nil {
+		abortWithScheduledStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func ScheduledStatusDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err = misskey.ScheduledStatusDelete(ctx, c.Param("id")); err != nil {
+		abortWithScheduledStatusError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
@@ -17,7 +17,10 @@ func StatusesRouter(r *gin.RouterGroup) {
 	group := r.Group("/statuses")
 	group.POST("", PostNewStatus)
 	group.GET("/:id", StatusHandler)
+	group.PUT("/:id", StatusEditHandler)
 	group.DELETE("/:id", StatusDeleteHandler)
+	group.GET("/:id/history", StatusHistoryHandler)
+	group.GET("/:id/source", StatusSourceHandler)
 	group.GET("/:id/context", StatusContextHandler)
 	group.GET("/:id/reblogged_by", StatusRebloggedByHandler)
 	group.GET("/:id/favourited_by", StatusFavouritedByHandler)
@@ -36,20 +39,25 @@ func StatusesRouter(r *gin.RouterGroup) {
 
 func StatusHandler(c *gin.Context) {
 	id := c.Param("id")
-	ctx, _ := misstodon.ContextWithGinContext(c)
+	ctx := misstodon.ContextWithOptionalToken(c)
 	info, err := misskey.StatusSingle(ctx, id)
 	if err != nil {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	misskey.FiltersApplyStatus(ctx, models.FilterContextThread, &info)
 	c.JSON(http.StatusOK, info)
 }
 
 func StatusContextHandler(c *gin.Context) {
 	id := c.Param("id")
-	ctx, _ := misstodon.ContextWithGinContext(c)
+	ctx := misstodon.ContextWithOptionalToken(c)
 	context, err := misskey.StatusContext(ctx, id)
 	if err != nil {
+		if errors.Is(err, misskey.ErrNotFound) {
+			c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+			return
+		}
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -396,15 +404,19 @@ type postNewStatusPollForm struct {
 }
 
 type postNewStatusForm struct {
-	Status      *string                 `json:"status"`
-	Poll        *postNewStatusPollForm  `json:"poll"`
-	MediaIDs    []string                `json:"media_ids"`
-	InReplyToID string                  `json:"in_reply_to_id"`
-	Sensitive   bool                    `json:"sensitive"`
-	SpoilerText string                  `json:"spoiler_text"`
-	Visibility  models.StatusVisibility `json:"visibility"`
-	Language    string                  `json:"language"`
-	ScheduledAt time.Time               `json:"scheduled_at"`
+	Status      *string                `json:"status"`
+	Poll        *postNewStatusPollForm `json:"poll"`
+	MediaIDs    []string               `json:"media_ids"`
+	InReplyToID string                 `json:"in_reply_to_id"`
+	ChannelID   string                 `json:"channel_id"`
+	// QuotedStatusID is the quoted status of Mastodon, QuoteID the one of Fedibird.
+	QuotedStatusID string                  `json:"quoted_status_id"`
+	QuoteID        string                  `json:"quote_id"`
+	Sensitive      bool                    `json:"sensitive"`
+	SpoilerText    string                  `json:"spoiler_text"`
+	Visibility     models.StatusVisibility `json:"visibility"`
+	Language       string                  `json:"language"`
+	ScheduledAt    time.Time               `json:"scheduled_at"`
 }
 
 func PostNewStatus(c *gin.Context) {
@@ -427,15 +439,102 @@ func PostNewStatus(c *gin.Context) {
 		pollExpiresIn = form.Poll.ExpiresIn
 		pollMultiple = form.Poll.Multiple
 	}
-	status, err := misskey.PostNewStatus(ctx,
-		form.Status, pollOptions, pollExpiresIn, pollMultiple,
-		form.MediaIDs, form.InReplyToID,
-		form.Sensitive, form.SpoilerText,
-		form.Visibility, form.Language,
-		form.ScheduledAt)
-	if err != nil {
-		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+	quoteID, _ := utils.StrEvaluation(form.QuotedStatusID, form.QuoteID)
+	status, err := misskey.StatusCreateIdempotent(ctx, c.GetHeader("Idempotency-Key"), func() (any, error) {
+		return misskey.PostNewStatus(ctx,
+			form.Status, pollOptions, pollExpiresIn, pollMultiple,
+			form.MediaIDs, form.InReplyToID, form.ChannelID, quoteID,
+			form.Sensitive, form.SpoilerText,
+			form.Visibility, form.Language,
+			form.ScheduledAt)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, misskey.ErrScheduledAtTooSoon), errors.Is(err, misskey.ErrScheduledStatusesOverLimit),
+			errors.Is(err, misskey.ErrChatReplyEmpty), errors.Is(err, misskey.ErrQuoteEmpty):
+			c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		case errors.Is(err, misskey.ErrUnauthorized):
+			c.JSON(http.StatusUnauthorized, httperror.ServerError{Error: err.Error()})
+		default:
+			httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if _, ok := status.(models.ScheduledStatus); ok {
+		c.JSON(http.StatusOK, status)
 		return
 	}
 	c.JSON(http.StatusCreated, status)
 }
+
+type statusEditForm struct {
+	Status      *string                `json:"status"`
+	SpoilerText string                 `json:"spoiler_text"`
+	Sensitive   bool                   `json:"sensitive"`
+	MediaIDs    []string               `json:"media_ids"`
+	Poll        *postNewStatusPollForm `json:"poll"`
+}
+
+func abortWithStatusEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+	case errors.Is(err, misskey.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, httperror.ServerError{Error: err.Error()})
+	case errors.Is(err, misskey.ErrEditNotSupported):
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+	case errors.Is(err, misskey.ErrNotSupported):
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "The server does not support editing statuses"})
+	default:
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+	}
+}
+
+func StatusEditHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form statusEditForm
+	if err = c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	var pollOptions []string
+	if form.Poll != nil {
+		pollOptions = utils.SliceIfNull(form.Poll.Options)
+	}
+	status, err := misskey.StatusEdit(ctx, c.Param("id"),
+		form.Status, form.Sensitive, form.SpoilerText,
+		form.MediaIDs, pollOptions)
+	if err != nil {
+		abortWithStatusEditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func StatusHistoryHandler(c *gin.Context) {
+	ctx := misstodon.ContextWithOptionalToken(c)
+	history, err := misskey.StatusHistory(ctx, c.Param("id"))
+	if err != nil {
+		abortWithStatusEditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+func StatusSourceHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	source, err := misskey.StatusSource(ctx, c.Param("id"))
+	if err != nil {
+		abortWithStatusEditError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, source)
+}
@@ -1,12 +1,16 @@
 package v1
 
 import (
-	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gizmo-ds/misstodon/internal/api/httperror"
-	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/internal/utils"
 	"github.com/gizmo-ds/misstodon/proxy/misskey/streaming"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
@@ -14,14 +18,49 @@ import (
 )
 
 var wsUpgrade = websocket.Upgrader{
-	ReadBufferSize:  4096, // we don't expect reads
+	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
 	Subprotocols:    []string{},
-	CheckOrigin: func(r *http.Request) bool { return true },
+	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
+// sseHeartbeatInterval is the interval of the comments sent to keep idle event streams open.
+const sseHeartbeatInterval = 15 * time.Second
+
 func StreamingRouter(r *gin.RouterGroup) {
 	r.GET("/streaming", StreamingHandler)
+	group := r.Group("/streaming")
+	group.GET("/health", func(c *gin.Context) { c.String(http.StatusOK, "OK") })
+	for path, stream := range map[string]string{
+		"/user":              streaming.StreamUser,
+		"/user/notification": streaming.StreamUserNotification,
+		"/public":            streaming.StreamPublic,
+		"/public/local":      streaming.StreamPublicLocal,
+		"/public/remote":     streaming.StreamPublicRemote,
+		"/hashtag":           streaming.StreamHashtag,
+		"/hashtag/local":     streaming.StreamHashtagLocal,
+		"/list":              streaming.StreamList,
+		"/direct":            streaming.StreamDirect,
+	} {
+		group.GET(path, StreamingSSEHandler(stream))
+	}
+}
+
+// streamingToken returns the Misskey token of the access token
+// issued by misstodon, which is "<user id>.<misskey token>".
+func streamingToken(token string) string {
+	if i := strings.LastIndex(token, "."); i >= 0 {
+		return token[i+1:]
+	}
+	return token
+}
+
+// streamingMessage is a message sent by the client over the streaming websocket.
+type streamingMessage struct {
+	Type   string `json:"type"`
+	Stream string `json:"stream"`
+	Tag    string `json:"tag"`
+	List   string `json:"list"`
 }
 
 func StreamingHandler(c *gin.Context) {
@@ -32,8 +71,16 @@ func StreamingHandler(c *gin.Context) {
 			return
 		}
 	}
+	token = streamingToken(token)
 	server := c.GetString("proxy-server")
 
+	client, err := streaming.DefaultHub.Connect(c.Request.Context(), server, token)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusBadGateway, err)
+		return
+	}
+	defer client.Close()
+
 	conn, err := wsUpgrade.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
@@ -41,43 +88,110 @@ func StreamingHandler(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	ch := make(chan models.StreamEvent)
-	defer close(ch)
-	go func() {
-		if err := streaming.Streaming(ctx, server, token, ch); err != nil {
-			log.Debug().Caller().Err(err).Msg("Streaming error")
+	var writeMu sync.Mutex
+	write := func(v any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(v)
+	}
+	subscribe := func(msg streamingMessage) {
+		sub := streaming.Subscription{Stream: msg.Stream, Tag: msg.Tag, List: msg.List}
+		if msg.Type == "unsubscribe" {
+			client.Unsubscribe(sub)
+			return
 		}
-		_ = conn.Close()
-	}()
+		if err := client.Subscribe(sub); err != nil {
+			_ = write(gin.H{"error": err.Error(), "status": http.StatusBadRequest})
+		}
+	}
 
 	go func() {
+		defer conn.Close()
 		for {
 			select {
-			case <-ctx.Done():
+			case <-client.Done():
 				return
-			case event := <-ch:
-				log.Debug().Caller().Any("event", event).Msg("Streaming")
+			case event := <-client.Events():
+				if err := write(event); err != nil {
+					log.Debug().Caller().Err(err).Msg("Streaming write error")
+					return
+				}
 			}
 		}
 	}()
 
+	if stream := c.Query("stream"); stream != "" {
+		subscribe(streamingMessage{Type: "subscribe", Stream: stream, Tag: c.Query("tag"), List: c.Query("list")})
+	}
 	for {
-		select {
-		case <-ctx.Done():
+		var msg streamingMessage
+		if err = conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*json.SyntaxError); ok {
+				continue
+			}
+			return
+		}
+		switch msg.Type {
+		case "subscribe", "unsubscribe":
+			subscribe(msg)
+		}
+	}
+}
+
+// StreamingSSEHandler returns a handler that sends the events of the stream as Server-Sent Events.
+// Events carry no ID, a reconnecting client starts over with new events.
+func StreamingSSEHandler(stream string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Query("access_token")
+		if token == "" {
+			token, _ = utils.GetHeaderToken(c.Request.Header)
+		}
+		sub := streaming.Subscription{Stream: stream, Tag: c.Query("tag"), List: c.Query("list")}
+		if err := sub.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
 			return
-		default:
 		}
-		_, _, err = conn.ReadMessage()
+		if token == "" && !strings.HasPrefix(stream, "public") && !strings.HasPrefix(stream, "hashtag") {
+			httperror.AbortWithError(c, http.StatusUnauthorized, errors.New("no access token provided"))
+			return
+		}
+
+		client, err := streaming.DefaultHub.Connect(c.Request.Context(), c.GetString("proxy-server"), streamingToken(token))
 		if err != nil {
-			if _, ok := err.(*websocket.CloseError); ok {
-				cancel()
+			httperror.AbortWithError(c, http.StatusBadGateway, err)
+			return
+		}
+		defer client.Close()
+		if err = client.Subscribe(sub); err != nil {
+			httperror.AbortWithError(c, http.StatusBadGateway, err)
+			return
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		_, _ = fmt.Fprint(c.Writer, ":)\n\n")
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(sseHeartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			var err error
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-client.Done():
 				return
+			case <-heartbeat.C:
+				_, err = fmt.Fprint(c.Writer, ":thump\n\n")
+			case event := <-client.Events():
+				_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Event, event.Payload)
 			}
-			httperror.AbortWithError(c, http.StatusInternalServerError, err)
-			return
+			if err != nil {
+				return
+			}
+			c.Writer.Flush()
 		}
 	}
 }
@@ -17,10 +17,11 @@ func TimelinesRouter(r *gin.RouterGroup) {
 	group.GET("/public", TimelinePublicHandler)
 	group.GET("/home", TimelineHomeHandler)
 	group.GET("/tag/:hashtag", TimelineHashtag)
+	group.GET("/list/:id", TimelineListHandler)
 }
 
 func TimelinePublicHandler(c *gin.Context) {
-	ctx, _ := misstodon.ContextWithGinContext(c)
+	ctx := misstodon.ContextWithOptionalToken(c)
 	limit := 20
 	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
 		limit = v
@@ -39,6 +40,7 @@ func TimelinePublicHandler(c *gin.Context) {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	list = misskey.FiltersApply(ctx, models.FilterContextPublic, list)
 	c.JSON(http.StatusOK, utils.SliceIfNull(list))
 }
 
@@ -61,11 +63,12 @@ func TimelineHomeHandler(c *gin.Context) {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	list = misskey.FiltersApply(ctx, models.FilterContextHome, list)
 	c.JSON(http.StatusOK, utils.SliceIfNull(list))
 }
 
 func TimelineHashtag(c *gin.Context) {
-	ctx, _ := misstodon.ContextWithGinContext(c)
+	ctx := misstodon.ContextWithOptionalToken(c)
 
 	limit := 20
 	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
@@ -81,5 +84,30 @@ func TimelineHashtag(c *gin.Context) {
 		httperror.AbortWithError(c, http.StatusInternalServerError, err)
 		return
 	}
+	list = misskey.FiltersApply(ctx, models.FilterContextPublic, list)
+	c.JSON(http.StatusOK, utils.SliceIfNull(list))
+}
+
+func TimelineListHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	limit := 20
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
+		limit = v
+		if limit > 40 {
+			limit = 40
+		}
+	}
+	minID, _ := utils.StrEvaluation(c.Query("min_id"), c.Query("since_id"))
+	list, err := misskey.TimelineList(ctx, c.Param("id"),
+		limit, c.Query("max_id"), minID)
+	if err != nil {
+		abortWithListError(c, err)
+		return
+	}
+	list = misskey.FiltersApply(ctx, models.FilterContextHome, list)
 	c.JSON(http.StatusOK, utils.SliceIfNull(list))
 }
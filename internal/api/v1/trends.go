@@ -15,6 +15,7 @@ func TrendsRouter(r *gin.RouterGroup) {
 	group := r.Group("/trends")
 	group.GET("/tags", TrendsTagsHandler)
 	group.GET("/statuses", TrendsStatusHandler)
+	group.GET("/links", TrendsLinksHandler)
 }
 
 func TrendsTagsHandler(c *gin.Context) {
@@ -54,3 +55,18 @@ func TrendsStatusHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, utils.SliceIfNull(statuses))
 }
+
+func TrendsLinksHandler(c *gin.Context) {
+	limit := 10
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
+		limit = utils.NumRangeLimit(v, 1, 20)
+	}
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	ctx, _ := misstodon.ContextWithGinContext(c)
+	links, err := misskey.TrendsLinks(ctx, limit, offset)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(links))
+}
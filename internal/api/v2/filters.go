@@ -0,0 +1,461 @@
+package v2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gizmo-ds/misstodon/internal/api/httperror"
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+func FiltersRouter(r *gin.RouterGroup) {
+	group := r.Group("/filters")
+	group.GET("", FiltersHandler)
+	group.POST("", FilterCreateHandler)
+	group.GET("/:id", FilterHandler)
+	group.PUT("/:id", FilterUpdateHandler)
+	group.DELETE("/:id", FilterDeleteHandler)
+	group.GET("/:id/keywords", FilterKeywordsHandler)
+	group.POST("/:id/keywords", FilterKeywordCreateHandler)
+	group.GET("/keywords/:id", FilterKeywordHandler)
+	group.PUT("/keywords/:id", FilterKeywordUpdateHandler)
+	group.DELETE("/keywords/:id", FilterKeywordDeleteHandler)
+	group.GET("/:id/statuses", FilterStatusesHandler)
+	group.POST("/:id/statuses", FilterStatusCreateHandler)
+	group.GET("/statuses/:id", FilterStatusHandler)
+	group.DELETE("/statuses/:id", FilterStatusDeleteHandler)
+}
+
+func abortWithFilterError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, misskey.ErrNotFound):
+		c.JSON(http.StatusNotFound, httperror.ServerError{Error: "Record not found"})
+	case errors.Is(err, misskey.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, httperror.ServerError{Error: err.Error()})
+	default:
+		httperror.AbortWithError(c, http.StatusInternalServerError, err)
+	}
+}
+
+type filterKeywordForm struct {
+	ID        string `json:"id"`
+	Keyword   string `json:"keyword"`
+	WholeWord bool   `json:"whole_word"`
+	Destroy   bool   `json:"_destroy"`
+}
+
+// filterForm holds the parameters of a filter, nil fields are left unchanged by an update.
+type filterForm struct {
+	Title              *string                `json:"title"`
+	Context            []models.FilterContext `json:"context"`
+	FilterAction       *models.FilterAction   `json:"filter_action"`
+	ExpiresIn          any                    `json:"expires_in"`
+	KeywordsAttributes []filterKeywordForm    `json:"keywords_attributes"`
+}
+
+// formBool parses the boolean values sent by Mastodon clients.
+func formBool(v string) bool {
+	switch strings.ToLower(v) {
+	case "1", "true", "on":
+		return true
+	}
+	return false
+}
+
+// bindFilterForm reads a filter from a JSON body or form values, keywords_attributes is
+// accepted both as keywords_attributes[][keyword] and keywords_attributes[0][keyword].
+func bindFilterForm(c *gin.Context) (form filterForm, err error) {
+	if strings.HasPrefix(c.ContentType(), binding.MIMEJSON) {
+		err = c.ShouldBindJSON(&form)
+		return
+	}
+	if v, ok := c.GetPostForm("title"); ok {
+		form.Title = &v
+	}
+	if v, ok := c.GetPostFormArray("context[]"); ok {
+		form.Context = v
+	}
+	if v, ok := c.GetPostForm("filter_action"); ok {
+		form.FilterAction = &v
+	}
+	if v, ok := c.GetPostForm("expires_in"); ok {
+		form.ExpiresIn = v
+	}
+	keywords := c.PostFormArray("keywords_attributes[][keyword]")
+	wholeWords := c.PostFormArray("keywords_attributes[][whole_word]")
+	ids := c.PostFormArray("keywords_attributes[][id]")
+	destroys := c.PostFormArray("keywords_attributes[][_destroy]")
+	for i, keyword := range keywords {
+		k := filterKeywordForm{Keyword: keyword}
+		if i < len(wholeWords) {
+			k.WholeWord = formBool(wholeWords[i])
+		}
+		if i < len(ids) {
+			k.ID = ids[i]
+		}
+		if i < len(destroys) {
+			k.Destroy = formBool(destroys[i])
+		}
+		form.KeywordsAttributes = append(form.KeywordsAttributes, k)
+	}
+	for i := 0; ; i++ {
+		prefix := "keywords_attributes[" + strconv.Itoa(i) + "]"
+		keyword, ok := c.GetPostForm(prefix + "[keyword]")
+		id, hasID := c.GetPostForm(prefix + "[id]")
+		if !ok && !hasID {
+			break
+		}
+		form.KeywordsAttributes = append(form.KeywordsAttributes, filterKeywordForm{
+			ID:        id,
+			Keyword:   keyword,
+			WholeWord: formBool(c.PostForm(prefix + "[whole_word]")),
+			Destroy:   formBool(c.PostForm(prefix + "[_destroy]")),
+		})
+	}
+	return
+}
+
+// expiresInSeconds returns the expires_in parameter in seconds, 0 if the filter never expires.
+func expiresInSeconds(v any) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case string:
+		seconds, _ := strconv.Atoi(v)
+		return seconds
+	}
+	return 0
+}
+
+// apply updates the filter with the parameters of the form.
+func (form filterForm) apply(f *models.Filter) {
+	if form.Title != nil {
+		f.Title = *form.Title
+	}
+	if form.Context != nil {
+		f.Context = form.Context
+	}
+	if form.FilterAction != nil {
+		f.FilterAction = *form.FilterAction
+	}
+	if form.ExpiresIn != nil {
+		f.SetExpiresIn(expiresInSeconds(form.ExpiresIn))
+	}
+	for _, k := range form.KeywordsAttributes {
+		if k.ID == "" {
+			if k.Keyword != "" && !k.Destroy {
+				f.Keywords = append(f.Keywords, models.FilterKeyword{Keyword: k.Keyword, WholeWord: k.WholeWord})
+			}
+			continue
+		}
+		for i, keyword := range f.Keywords {
+			if keyword.ID != k.ID {
+				continue
+			}
+			if k.Destroy {
+				f.Keywords = append(f.Keywords[:i], f.Keywords[i+1:]...)
+			} else {
+				if k.Keyword != "" {
+					f.Keywords[i].Keyword = k.Keyword
+				}
+				f.Keywords[i].WholeWord = k.WholeWord
+			}
+			break
+		}
+	}
+}
+
+func FiltersHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filters, err := misskey.Filters(ctx)
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, utils.SliceIfNull(filters))
+}
+
+func FilterHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter)
+}
+
+func FilterCreateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := bindFilterForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	filter := models.Filter{FilterAction: models.FilterActionWarn}
+	form.apply(&filter)
+	if err = filter.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter)
+}
+
+func FilterUpdateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	form, err := bindFilterForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	form.apply(&filter)
+	if err = filter.Validate(); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter)
+}
+
+func FilterDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err = misskey.FilterDelete(ctx, c.Param("id")); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func FilterKeywordsHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.Keywords)
+}
+
+type keywordForm struct {
+	Keyword   *string `json:"keyword" form:"keyword"`
+	WholeWord *bool   `json:"whole_word" form:"whole_word"`
+}
+
+func FilterKeywordCreateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form keywordForm
+	if err = c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if form.Keyword == nil || *form.Keyword == "" {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Keyword can't be blank"})
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	filter.Keywords = append(filter.Keywords, models.FilterKeyword{
+		Keyword:   *form.Keyword,
+		WholeWord: form.WholeWord != nil && *form.WholeWord,
+	})
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.Keywords[len(filter.Keywords)-1])
+}
+
+func FilterKeywordHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	_, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keyword)
+}
+
+func FilterKeywordUpdateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form keywordForm
+	if err = c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	filter, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	if form.Keyword != nil && *form.Keyword != "" {
+		keyword.Keyword = *form.Keyword
+	}
+	if form.WholeWord != nil {
+		keyword.WholeWord = *form.WholeWord
+	}
+	for i := range filter.Keywords {
+		if filter.Keywords[i].ID == keyword.ID {
+			filter.Keywords[i] = keyword
+		}
+	}
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keyword)
+}
+
+func FilterKeywordDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, keyword, err := misskey.FilterKeywordGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	filter.Keywords = lo.Filter(filter.Keywords, func(k models.FilterKeyword, _ int) bool { return k.ID != keyword.ID })
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func FilterStatusesHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.Statuses)
+}
+
+func FilterStatusCreateHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	var form struct {
+		StatusID string `json:"status_id" form:"status_id"`
+	}
+	if err = c.ShouldBind(&form); err != nil {
+		c.JSON(http.StatusBadRequest, httperror.ServerError{Error: err.Error()})
+		return
+	}
+	if form.StatusID == "" {
+		c.JSON(http.StatusUnprocessableEntity, httperror.ServerError{Error: "Validation failed: Status can't be blank"})
+		return
+	}
+	filter, err := misskey.FilterGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	filter.Statuses = append(filter.Statuses, models.FilterStatus{StatusID: form.StatusID})
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filter.Statuses[len(filter.Statuses)-1])
+}
+
+func FilterStatusHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	_, status, err := misskey.FilterStatusGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func FilterStatusDeleteHandler(c *gin.Context) {
+	ctx, err := misstodon.ContextWithGinContext(c, true)
+	if err != nil {
+		httperror.AbortWithError(c, http.StatusUnauthorized, err)
+		return
+	}
+	filter, status, err := misskey.FilterStatusGet(ctx, c.Param("id"))
+	if err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	filter.Statuses = lo.Filter(filter.Statuses, func(s models.FilterStatus, _ int) bool { return s.ID != status.ID })
+	if err = misskey.FilterSave(ctx, &filter); err != nil {
+		abortWithFilterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
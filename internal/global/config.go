@@ -6,7 +6,8 @@ import (
 
 type config struct {
 	Proxy struct {
-		FallbackServer string `toml:"fallback_server" yaml:"fallback_server"  env:"MISSTODON_FALLBACK_SERVER"`
+		FallbackServer    string   `toml:"fallback_server" yaml:"fallback_server"  env:"MISSTODON_FALLBACK_SERVER"`
+		InsecureUpstreams []string `toml:"insecure_upstreams" yaml:"insecure_upstreams" env:"MISSTODON_INSECURE_UPSTREAMS"`
 	} `toml:"proxy" yaml:"proxy"`
 	Server struct {
 		BindAddress string `toml:"bind_address" yaml:"bind_address" env:"MISSTODON_SERVER_BIND_ADDRESS"`
@@ -15,6 +16,20 @@ type config struct {
 		TlsCertFile string `toml:"tls_cert_file" yaml:"tls_cert_file" env:"MISSTODON_SERVER_TLS_CERT_FILE"`
 		TlsKeyFile  string `toml:"tls_key_file" yaml:"tls_key_file" env:"MISSTODON_SERVER_TLS_KEY_FILE"`
 	} `toml:"server" yaml:"server"`
+	Database struct {
+		Type    string `toml:"type" yaml:"type" env:"MISSTODON_DATABASE_TYPE"`
+		Address string `toml:"address" yaml:"address" env:"MISSTODON_DATABASE_ADDRESS"`
+	} `toml:"database" yaml:"database"`
+	Markers struct {
+		Backend string `toml:"backend" yaml:"backend" env:"MISSTODON_MARKERS_BACKEND"`
+	} `toml:"markers" yaml:"markers"`
+	Statuses struct {
+		ContextLimit int `toml:"context_limit" yaml:"context_limit" env:"MISSTODON_STATUSES_CONTEXT_LIMIT"`
+	} `toml:"statuses" yaml:"statuses"`
+	Streaming struct {
+		PollingFallback bool `toml:"polling_fallback" yaml:"polling_fallback" env:"MISSTODON_STREAMING_POLLING_FALLBACK"`
+		PollInterval    int  `toml:"poll_interval" yaml:"poll_interval" env:"MISSTODON_STREAMING_POLL_INTERVAL"`
+	} `toml:"streaming" yaml:"streaming"`
 	Logger struct {
 		Level         int8   `toml:"level" yaml:"level" env:"MISSTODON_LOGGER_LEVEL"`
 		ConsoleWriter bool   `toml:"console_writer" yaml:"console_writer" env:"MISSTODON_LOGGER_CONSOLE_WRITER"`
@@ -261,13 +261,14 @@ func appendChildren(parent *html.Node, children []MfmNode, option ...Option) {
 			}
 			acct := child.Props["acct"].(string)
 			username, host := utils.AcctInfo(acct)
-			if host == "" {
-				host = option[0].Url[8:]
+			href := option[0].Url + "/@" + username
+			if host != "" {
+				href = utils.JoinURL(host, "/@", username)
 			}
 			a.Attr = append(a.Attr,
 				html.Attribute{
 					Key: "href",
-					Val: "https://" + host + "/@" + username,
+					Val: href,
 				},
 				html.Attribute{
 					Key: "class",
@@ -299,3 +300,30 @@ func appendChildren(parent *html.Node, children []MfmNode, option ...Option) {
 		}
 	}
 }
+
+// Urls returns the URLs of the links in the text, in order.
+// Silent links are left out, as Misskey does not preview them.
+func Urls(text string) []string {
+	nodes, err := Parse(text)
+	if err != nil {
+		return nil
+	}
+	return appendUrls(nil, nodes)
+}
+
+func appendUrls(urls []string, nodes []MfmNode) []string {
+	for _, node := range nodes {
+		switch node.Type {
+		case nodeTypeUrl:
+			urls = append(urls, node.Props["url"].(string))
+			continue
+		case nodeTypeLink:
+			if silent, _ := node.Props["silent"].(bool); !silent {
+				urls = append(urls, node.Props["url"].(string))
+			}
+			continue
+		}
+		urls = appendUrls(urls, node.Children)
+	}
+	return urls
+}
@@ -224,6 +224,13 @@ func TestToHtml(t *testing.T) {
 	})
 }
 
+func TestUrls(t *testing.T) {
+	assert.Equal(t,
+		[]string{"https://example.com/a", "https://example.com/b"},
+		mfm.Urls("@user https://example.com/a **[b](https://example.com/b)** ?[c](https://example.com/c) `https://example.com/d`"))
+	assert.Empty(t, mfm.Urls("#hello @user@example.com"))
+}
+
 func TestCustomHashtagHandler(t *testing.T) {
 	s, err := mfm.ToHtml("#hello", mfm.Option{
 		Url:            "https://misskey.io",
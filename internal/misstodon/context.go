@@ -35,6 +35,16 @@ func ContextWithGinContext(gCtx *gin.Context, tokenRequired ...bool) (*Context,
 	return c, nil
 }
 
+// ContextWithOptionalToken returns the context of the user if the request has a valid access token,
+// and an anonymous context otherwise.
+func ContextWithOptionalToken(gCtx *gin.Context) *Context {
+	if c, err := ContextWithGinContext(gCtx, true); err == nil {
+		return c
+	}
+	c, _ := ContextWithGinContext(gCtx)
+	return c
+}
+
 func ContextWithValues(proxyServer, token string) *Context {
 	c := &Context{}
 	c.SetProxyServer(proxyServer)
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tidwall/buntdb"
+)
+
+type buntDB struct {
+	db *buntdb.DB
+}
+
+// OpenBuntDB opens the buntdb database file at path, ":memory:" opens an in-memory database.
+func OpenBuntDB(path string) (Storage, error) {
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return nil, err
+		}
+	}
+	db, err := buntdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &buntDB{db: db}, nil
+}
+
+func (s *buntDB) Get(key string) (value string, err error) {
+	err = s.db.View(func(tx *buntdb.Tx) error {
+		value, err = tx.Get(key)
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		err = ErrNotFound
+	}
+	return
+}
+
+func (s *buntDB) Set(key, value string, ttl time.Duration) error {
+	var opts *buntdb.SetOptions
+	if ttl > 0 {
+		opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+	}
+	return s.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(key, value, opts)
+		return err
+	})
+}
+
+func (s *buntDB) Delete(key string) error {
+	err := s.db.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(key)
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *buntDB) Ascend(prefix string, fn func(key, value string) bool) error {
+	return s.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendGreaterOrEqual("", prefix, func(key, value string) bool {
+			if !strings.HasPrefix(key, prefix) {
+				return false
+			}
+			return fn(key, value)
+		})
+	})
+}
+
+func (s *buntDB) CreateIndex(name, prefix, field string) error {
+	return s.db.ReplaceIndex(name, prefix+"*", buntdb.IndexJSON(field))
+}
+
+func (s *buntDB) AscendIndex(name string, fn func(key, value string) bool) error {
+	return s.db.View(func(tx *buntdb.Tx) error {
+		return tx.Ascend(name, fn)
+	})
+}
+
+func (s *buntDB) Close() error {
+	return s.db.Close()
+}
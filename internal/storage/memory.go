@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryIndex struct {
+	prefix, field string
+}
+
+type memory struct {
+	mu      sync.RWMutex
+	items   map[string]memoryItem
+	indexes map[string]memoryIndex
+}
+
+// NewMemory returns a storage that keeps everything in memory, it is meant for tests.
+func NewMemory() Storage {
+	return &memory{
+		items:   make(map[string]memoryItem),
+		indexes: make(map[string]memoryIndex),
+	}
+}
+
+func (i memoryItem) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+func (s *memory) Get(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[key]
+	if !ok || item.expired(time.Now()) {
+		return "", ErrNotFound
+	}
+	return item.value, nil
+}
+
+func (s *memory) Set(key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	s.items[key] = item
+	return nil
+}
+
+func (s *memory) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+type memoryEntry struct {
+	key, value string
+}
+
+// entries returns the live entries with the prefix in ascending key order.
+func (s *memory) entries(prefix string) []memoryEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	var entries []memoryEntry
+	for k, item := range s.items {
+		if strings.HasPrefix(k, prefix) && !item.expired(now) {
+			entries = append(entries, memoryEntry{key: k, value: item.value})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	return entries
+}
+
+func (s *memory) Ascend(prefix string, fn func(key, value string) bool) error {
+	for _, e := range s.entries(prefix) {
+		if !fn(e.key, e.value) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memory) CreateIndex(name, prefix, field string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexes[name] = memoryIndex{prefix: prefix, field: field}
+	return nil
+}
+
+func (s *memory) AscendIndex(name string, fn func(key, value string) bool) error {
+	s.mu.RLock()
+	index, ok := s.indexes[name]
+	s.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	entries := s.entries(index.prefix)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return gjson.Get(entries[i].value, index.field).Less(gjson.Get(entries[j].value, index.field), false)
+	})
+	for _, e := range entries {
+		if !fn(e.key, e.value) {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memory) Close() error {
+	return nil
+}
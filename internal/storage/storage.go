@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrUnknownType = errors.New("unknown database type")
+)
+
+const (
+	TypeBuntDB = "buntdb"
+	TypeMemory = "memory"
+)
+
+// Storage is a key/value store with indexes ordered by a JSON field of the values.
+// The callbacks of Ascend and AscendIndex must not modify the storage.
+type Storage interface {
+	// Get returns the value of the key, or ErrNotFound.
+	Get(key string) (string, error)
+	// Set stores the value of the key, it expires after ttl if ttl is positive.
+	Set(key, value string, ttl time.Duration) error
+	// Delete removes the key, deleting a missing key is not an error.
+	Delete(key string) error
+	// Ascend calls fn for the keys with the prefix in ascending order until fn returns false.
+	Ascend(prefix string, fn func(key, value string) bool) error
+	// CreateIndex creates an index of the keys with the prefix, ordered by the JSON field of their values.
+	CreateIndex(name, prefix, field string) error
+	// AscendIndex calls fn for the keys of the index in ascending order until fn returns false.
+	AscendIndex(name string, fn func(key, value string) bool) error
+	Close() error
+}
+
+// Default is the storage used by the proxy, it is in memory until Init is called.
+var Default Storage = NewMemory()
+
+// Open opens the storage of the type, address is the database file of buntdb.
+func Open(typ, address string) (Storage, error) {
+	switch typ {
+	case TypeBuntDB:
+		return OpenBuntDB(address)
+	case TypeMemory, "":
+		return NewMemory(), nil
+	}
+	return nil, ErrUnknownType
+}
+
+// Init opens the storage of the type and makes it the default storage.
+func Init(typ, address string) error {
+	s, err := Open(typ, address)
+	if err != nil {
+		return err
+	}
+	Default = s
+	return nil
+}
+
+// Key builds the key of proxy-side state of a user, keyed by upstream server and user ID.
+func Key(server, userID string, parts ...string) string {
+	return strings.Join(append([]string{server, userID}, parts...), ":")
+}
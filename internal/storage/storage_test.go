@@ -0,0 +1,60 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	for _, typ := range []string{storage.TypeMemory, storage.TypeBuntDB} {
+		t.Run(typ, func(t *testing.T) {
+			s, err := storage.Open(typ, ":memory:")
+			require.NoError(t, err)
+			defer s.Close()
+
+			_, err = s.Get("a")
+			assert.ErrorIs(t, err, storage.ErrNotFound)
+
+			require.NoError(t, s.Set("a:2", `{"at":1}`, 0))
+			require.NoError(t, s.Set("a:1", `{"at":3}`, 0))
+			require.NoError(t, s.Set("a:3", `{"at":2}`, 0))
+			require.NoError(t, s.Set("b:1", `{"at":0}`, 0))
+			value, err := s.Get("a:1")
+			require.NoError(t, err)
+			assert.Equal(t, `{"at":3}`, value)
+
+			var keys []string
+			require.NoError(t, s.Ascend("a:", func(key, _ string) bool {
+				keys = append(keys, key)
+				return true
+			}))
+			assert.Equal(t, []string{"a:1", "a:2", "a:3"}, keys)
+
+			require.NoError(t, s.CreateIndex("a_at", "a:", "at"))
+			keys = nil
+			require.NoError(t, s.AscendIndex("a_at", func(key, _ string) bool {
+				keys = append(keys, key)
+				return len(keys) < 2
+			}))
+			assert.Equal(t, []string{"a:2", "a:3"}, keys)
+
+			require.NoError(t, s.Delete("a:1"))
+			require.NoError(t, s.Delete("a:1"))
+			_, err = s.Get("a:1")
+			assert.ErrorIs(t, err, storage.ErrNotFound)
+
+			require.NoError(t, s.Set("ttl", "v", 50*time.Millisecond))
+			time.Sleep(100 * time.Millisecond)
+			_, err = s.Get("ttl")
+			assert.ErrorIs(t, err, storage.ErrNotFound)
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "misskey.io:9abc:markers:home", storage.Key("misskey.io", "9abc", "markers", "home"))
+}
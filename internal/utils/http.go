@@ -19,10 +19,33 @@ func GetHeaderToken(header http.Header) (string, error) {
 	return strings.Split(auth, " ")[1], nil
 }
 
+var insecureUpstreams []string
+
+// SetInsecureUpstreams sets the servers that are reached over plain HTTP and WebSocket
+// instead of HTTPS and secure WebSocket, e.g. a local Misskey used for development.
+func SetInsecureUpstreams(servers []string) {
+	insecureUpstreams = servers
+}
+
+// IsInsecureUpstream returns true if the server is reached over plain HTTP.
+func IsInsecureUpstream(server string) bool {
+	return Contains(insecureUpstreams, server)
+}
+
+// UpstreamScheme returns the scheme used to reach the server, "http" or "https".
+func UpstreamScheme(server string) string {
+	if IsInsecureUpstream(server) {
+		return "http"
+	}
+	return "https"
+}
+
+// JoinURL joins the server and the paths into a URL,
+// the scheme of the server is added if it has none.
 func JoinURL(server string, p ...string) string {
 	u := strings.Join(append([]string{server}, p...), "")
 	if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
-		u = "https://" + u
+		u = UpstreamScheme(server) + "://" + u
 	}
 	return u
 }
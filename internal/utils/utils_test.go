@@ -57,3 +57,13 @@ func TestGetFieldsAttributes(t *testing.T) {
 	assert.Equal(t, "Google", fields[2].Name)
 	assert.Equal(t, "https://google.com", fields[2].Value)
 }
+
+func TestJoinURL(t *testing.T) {
+	assert.Equal(t, "https://misskey.io/api/meta", utils.JoinURL("misskey.io", "/api/meta"))
+	assert.Equal(t, "http://localhost:3000/api/meta", utils.JoinURL("http://localhost:3000", "/api/meta"))
+
+	utils.SetInsecureUpstreams([]string{"localhost:3000"})
+	defer utils.SetInsecureUpstreams(nil)
+	assert.Equal(t, "http://localhost:3000/api/meta", utils.JoinURL("localhost:3000", "/api/meta"))
+	assert.Equal(t, "https://misskey.io/api/meta", utils.JoinURL("misskey.io", "/api/meta"))
+}
@@ -69,3 +69,13 @@ type CredentialAccount struct {
 		UpdatedAt   string `json:"updated_at"`
 	} `json:"role,omitempty"`
 }
+
+// ToStatusMention returns the mention of the account in a status.
+func (a Account) ToStatusMention() StatusMention {
+	return StatusMention{
+		Id:       a.ID,
+		Username: a.Username,
+		Url:      a.Url,
+		Acct:     a.Acct,
+	}
+}
@@ -11,6 +11,8 @@ var (
 		MkAppPermissionReadMessaging,
 		MkAppPermissionReadDrive,
 		MkAppPermissionReadReactions,
+		MkAppPermissionReadChat,
+		MkAppPermissionReadChannels,
 	}
 	ApplicationPermissionWrite = []string{
 		MkAppPermissionWriteAccount,
@@ -22,6 +24,7 @@ var (
 		MkAppPermissionWriteNotes,
 		MkAppPermissionWriteFavorites,
 		MkAppPermissionWriteReactions,
+		MkAppPermissionWriteChat,
 	}
 	ApplicationPermissionFollow = []string{
 		MkAppPermissionReadBlocks,
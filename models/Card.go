@@ -0,0 +1,99 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+)
+
+type CardType = string
+
+const (
+	CardTypeLink  CardType = "link"
+	CardTypePhoto CardType = "photo"
+	CardTypeVideo CardType = "video"
+	CardTypeRich  CardType = "rich"
+)
+
+type (
+	// Card is the preview of a link.
+	Card struct {
+		Url          string   `json:"url"`
+		Title        string   `json:"title"`
+		Description  string   `json:"description"`
+		Type         CardType `json:"type"`
+		AuthorName   string   `json:"author_name"`
+		AuthorUrl    string   `json:"author_url"`
+		ProviderName string   `json:"provider_name"`
+		ProviderUrl  string   `json:"provider_url"`
+		Html         string   `json:"html"`
+		Width        int      `json:"width"`
+		Height       int      `json:"height"`
+		Image        *string  `json:"image"`
+		EmbedUrl     string   `json:"embed_url"`
+		Blurhash     *string  `json:"blurhash"`
+	}
+	// TrendsLink is a link shared by many users.
+	TrendsLink struct {
+		Card
+		History []TrendsHistory `json:"history"`
+	}
+	TrendsHistory struct {
+		Day      string `json:"day"`
+		Uses     string `json:"uses"`
+		Accounts string `json:"accounts"`
+	}
+)
+
+// MkUrlSummary is the summary of a web page by the summary proxy of Misskey.
+type MkUrlSummary struct {
+	Url         string  `json:"url"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Icon        *string `json:"icon"`
+	Thumbnail   *string `json:"thumbnail"`
+	Sitename    *string `json:"sitename"`
+	Sensitive   bool    `json:"sensitive"`
+	Player      struct {
+		Url    *string `json:"url"`
+		Width  *int    `json:"width"`
+		Height *int    `json:"height"`
+	} `json:"player"`
+}
+
+// ToCard converts the summary of the page at link, the summary has the URL the page was redirected to.
+func (s MkUrlSummary) ToCard(link string) Card {
+	c := Card{
+		Url:   link,
+		Type:  CardTypeLink,
+		Image: s.Thumbnail,
+	}
+	if s.Url != "" {
+		c.Url = s.Url
+	}
+	if s.Title != nil {
+		c.Title = *s.Title
+	}
+	if s.Description != nil {
+		c.Description = *s.Description
+	}
+	if s.Sitename != nil {
+		c.ProviderName = *s.Sitename
+	}
+	if u, err := url.Parse(c.Url); err == nil && u.Host != "" {
+		c.ProviderUrl = u.Scheme + "://" + u.Host
+	}
+	if s.Player.Url != nil && *s.Player.Url != "" {
+		c.Type = CardTypeVideo
+		c.EmbedUrl = *s.Player.Url
+		if s.Player.Width != nil {
+			c.Width = *s.Player.Width
+		}
+		if s.Player.Height != nil {
+			c.Height = *s.Player.Height
+		}
+		c.Html = fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" frameborder="0" allowfullscreen="true"></iframe>`,
+			html.EscapeString(*s.Player.Url), c.Width, c.Height)
+	}
+	return c
+}
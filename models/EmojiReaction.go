@@ -0,0 +1,79 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// EmojiReaction is an emoji reaction of a status, as in Pleroma and Fedibird.
+type EmojiReaction struct {
+	// Name is the unicode emoji, or the shortcode of a custom emoji, with its domain if it is remote.
+	Name       string    `json:"name"`
+	Count      int       `json:"count"`
+	Me         bool      `json:"me"`
+	Url        *string   `json:"url,omitempty"`
+	StaticUrl  *string   `json:"static_url,omitempty"`
+	Domain     *string   `json:"domain,omitempty"`
+	AccountIDs []string  `json:"account_ids,omitempty"`
+	Accounts   []Account `json:"accounts,omitempty"`
+}
+
+// EmojiReactionName returns the name of a Misskey reaction, ":blobcat@.:" is "blobcat".
+func EmojiReactionName(reaction string) string {
+	if !strings.HasPrefix(reaction, ":") || !strings.HasSuffix(reaction, ":") || len(reaction) < 2 {
+		return reaction
+	}
+	return strings.TrimSuffix(strings.Trim(reaction, ":"), "@.")
+}
+
+// MkReaction returns the Misskey reaction of an emoji reaction name.
+func MkReaction(name string) string {
+	name = strings.Trim(name, ":")
+	for _, r := range name {
+		// Custom emoji shortcodes are ASCII, unicode emojis are not.
+		if r > 0x7f {
+			return name
+		}
+	}
+	return ":" + name + ":"
+}
+
+// ToEmojiReaction converts a Misskey reaction of a note, emojis are the URLs of the remote custom emojis
+// of the note and local the URLs of the custom emojis of the server.
+func ToEmojiReaction(reaction string, count int, myReaction string, emojis, local map[string]string) EmojiReaction {
+	r := EmojiReaction{
+		Name:  EmojiReactionName(reaction),
+		Count: count,
+		Me:    reaction == myReaction,
+	}
+	if r.Name == reaction {
+		return r
+	}
+	var url string
+	if _, domain, ok := strings.Cut(r.Name, "@"); ok {
+		url = emojis[r.Name]
+		r.Domain = &domain
+	} else {
+		url = local[r.Name]
+	}
+	if url != "" {
+		r.Url = &url
+		r.StaticUrl = &url
+	}
+	return r
+}
+
+// EmojiReactions returns the reactions of the note, the most used first, local are the custom emojis of the server.
+func (n *MkNote) EmojiReactions(local map[string]string) []EmojiReaction {
+	reactions := []EmojiReaction{}
+	for reaction, count := range n.Reactions {
+		reactions = append(reactions, ToEmojiReaction(reaction, count, n.MyReaction, n.ReactionEmojis, local))
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		if reactions[i].Count != reactions[j].Count {
+			return reactions[i].Count > reactions[j].Count
+		}
+		return reactions[i].Name < reactions[j].Name
+	})
+	return reactions
+}
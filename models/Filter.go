@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type FilterContext = string
+
+const (
+	FilterContextHome          FilterContext = "home"
+	FilterContextNotifications FilterContext = "notifications"
+	FilterContextPublic        FilterContext = "public"
+	FilterContextThread        FilterContext = "thread"
+	FilterContextAccount       FilterContext = "account"
+)
+
+type FilterAction = string
+
+const (
+	FilterActionWarn FilterAction = "warn"
+	FilterActionHide FilterAction = "hide"
+	FilterActionBlur FilterAction = "blur"
+)
+
+type (
+	Filter struct {
+		ID           string          `json:"id"`
+		Title        string          `json:"title"`
+		Context      []FilterContext `json:"context"`
+		ExpiresAt    *string         `json:"expires_at"`
+		FilterAction FilterAction    `json:"filter_action"`
+		Keywords     []FilterKeyword `json:"keywords"`
+		Statuses     []FilterStatus  `json:"statuses"`
+	}
+	FilterKeyword struct {
+		ID        string `json:"id"`
+		Keyword   string `json:"keyword"`
+		WholeWord bool   `json:"whole_word"`
+	}
+	FilterStatus struct {
+		ID       string `json:"id"`
+		StatusID string `json:"status_id"`
+	}
+	// FilterResult is a filter matched by a status.
+	FilterResult struct {
+		Filter         Filter   `json:"filter"`
+		KeywordMatches []string `json:"keyword_matches"`
+		StatusMatches  []string `json:"status_matches"`
+	}
+	// FilterV1 is a keyword of a filter in the form of the v1 filters API.
+	FilterV1 struct {
+		ID           string          `json:"id"`
+		Phrase       string          `json:"phrase"`
+		Context      []FilterContext `json:"context"`
+		WholeWord    bool            `json:"whole_word"`
+		ExpiresAt    *string         `json:"expires_at"`
+		Irreversible bool            `json:"irreversible"`
+	}
+)
+
+func (f Filter) ToFilterV1(keyword FilterKeyword) FilterV1 {
+	return FilterV1{
+		ID:           keyword.ID,
+		Phrase:       keyword.Keyword,
+		Context:      f.Context,
+		WholeWord:    keyword.WholeWord,
+		ExpiresAt:    f.ExpiresAt,
+		Irreversible: f.FilterAction == FilterActionHide,
+	}
+}
+
+// SetExpiresIn makes the filter expire in seconds, it never expires if seconds is not positive.
+func (f *Filter) SetExpiresIn(seconds int) {
+	f.ExpiresAt = nil
+	if seconds > 0 {
+		t := time.Now().Add(time.Duration(seconds) * time.Second).UTC().Format(time.RFC3339)
+		f.ExpiresAt = &t
+	}
+}
+
+func (f Filter) Expired(now time.Time) bool {
+	if f.ExpiresAt == nil {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, *f.ExpiresAt)
+	return err == nil && !now.Before(t)
+}
+
+// Validate returns the reason the filter cannot be saved, in the words of Mastodon.
+func (f Filter) Validate() error {
+	if f.Title == "" {
+		return errors.New("Validation failed: Title can't be blank")
+	}
+	if len(f.Context) == 0 {
+		return errors.New("Validation failed: Context can't be blank")
+	}
+	for _, context := range f.Context {
+		switch context {
+		case FilterContextHome, FilterContextNotifications, FilterContextPublic,
+			FilterContextThread, FilterContextAccount:
+		default:
+			return errors.New("Validation failed: Context is invalid")
+		}
+	}
+	switch f.FilterAction {
+	case FilterActionWarn, FilterActionHide, FilterActionBlur:
+	default:
+		return errors.New("Validation failed: Filter action is invalid")
+	}
+	return nil
+}
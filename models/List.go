@@ -0,0 +1,16 @@
+package models
+
+type ListRepliesPolicy = string
+
+const (
+	ListRepliesPolicyFollowed ListRepliesPolicy = "followed"
+	ListRepliesPolicyList     ListRepliesPolicy = "list"
+	ListRepliesPolicyNone     ListRepliesPolicy = "none"
+)
+
+type List struct {
+	ID            string            `json:"id"`
+	Title         string            `json:"title"`
+	RepliesPolicy ListRepliesPolicy `json:"replies_policy"`
+	Exclusive     bool              `json:"exclusive"`
+}
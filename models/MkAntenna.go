@@ -0,0 +1,35 @@
+package models
+
+import "strings"
+
+// AntennaListIDPrefix marks the IDs of the lists backed by Misskey antennas.
+const AntennaListIDPrefix = "antenna_"
+
+type MkAntenna struct {
+	ID          string   `json:"id"`
+	CreatedAt   string   `json:"createdAt"`
+	Name        string   `json:"name"`
+	Keywords    []string `json:"keywords"`
+	Src         string   `json:"src"`
+	UserListID  *string  `json:"userListId"`
+	Users       []string `json:"users"`
+	WithReplies bool     `json:"withReplies"`
+	IsActive    bool     `json:"isActive"`
+}
+
+func (a MkAntenna) ToList() List {
+	list := List{
+		ID:            AntennaListIDPrefix + a.ID,
+		Title:         a.Name,
+		RepliesPolicy: ListRepliesPolicyNone,
+	}
+	if a.WithReplies {
+		list.RepliesPolicy = ListRepliesPolicyList
+	}
+	return list
+}
+
+// AntennaID returns the antenna ID of a list ID, ok is false if the list is not backed by an antenna.
+func AntennaID(listID string) (id string, ok bool) {
+	return strings.CutPrefix(listID, AntennaListIDPrefix)
+}
@@ -34,6 +34,8 @@ const (
 	MkAppPermissionWriteGallery       = "write:gallery"
 	MkAppPermissionReadGalleryLikes   = "read:gallery-likes"
 	MkAppPermissionWriteGalleryLikes  = "write:gallery-likes"
+	MkAppPermissionReadChat           = "read:chat"
+	MkAppPermissionWriteChat          = "write:chat"
 )
 
 type MkApplication struct {
@@ -0,0 +1,27 @@
+package models
+
+import "strings"
+
+// ChannelListIDPrefix marks the IDs of the lists backed by Misskey channels.
+const ChannelListIDPrefix = "channel_"
+
+type MkChannel struct {
+	ID          string  `json:"id"`
+	CreatedAt   string  `json:"createdAt"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	IsFollowing bool    `json:"isFollowing"`
+}
+
+func (c MkChannel) ToList() List {
+	return List{
+		ID:            ChannelListIDPrefix + c.ID,
+		Title:         c.Name,
+		RepliesPolicy: ListRepliesPolicyList,
+	}
+}
+
+// ChannelID returns the channel ID of a list ID, ok is false if the list is not backed by a channel.
+func ChannelID(listID string) (id string, ok bool) {
+	return strings.CutPrefix(listID, ChannelListIDPrefix)
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/gizmo-ds/misstodon/internal/mfm"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+)
+
+const (
+	// ChatStatusIDPrefix marks the IDs of the statuses backed by Misskey chat messages.
+	ChatStatusIDPrefix = "chat_"
+	// ChatUserConversationIDPrefix marks the IDs of the conversations backed by one-to-one chats.
+	ChatUserConversationIDPrefix = "chat_user_"
+	// ChatRoomConversationIDPrefix marks the IDs of the conversations backed by chat rooms.
+	ChatRoomConversationIDPrefix = "chat_room_"
+)
+
+type MkChatMessage struct {
+	ID         string      `json:"id"`
+	CreatedAt  string      `json:"createdAt"`
+	FromUserId string      `json:"fromUserId"`
+	FromUser   *MkUser     `json:"fromUser"`
+	ToUserId   *string     `json:"toUserId"`
+	ToUser     *MkUser     `json:"toUser"`
+	ToRoomId   *string     `json:"toRoomId"`
+	ToRoom     *MkChatRoom `json:"toRoom"`
+	Text       *string     `json:"text"`
+	FileId     *string     `json:"fileId"`
+	File       *MkFile     `json:"file"`
+	IsRead     bool        `json:"isRead"`
+}
+
+type MkChatRoom struct {
+	ID          string  `json:"id"`
+	CreatedAt   string  `json:"createdAt"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	OwnerId     string  `json:"ownerId"`
+	Owner       *MkUser `json:"owner"`
+}
+
+// PeerID returns the ID of the other user of a one-to-one chat message, as seen by the user.
+func (m *MkChatMessage) PeerID(userID string) string {
+	if m.FromUserId == userID && m.ToUserId != nil {
+		return *m.ToUserId
+	}
+	return m.FromUserId
+}
+
+// Peer returns the user the user is chatting with, the owner of the room if the user sent a room message,
+// nil if Misskey did not send it.
+func (m *MkChatMessage) Peer(userID string) *MkUser {
+	if m.FromUserId != userID {
+		return m.FromUser
+	}
+	if m.ToRoom != nil && m.ToRoom.OwnerId != userID {
+		return m.ToRoom.Owner
+	}
+	return m.ToUser
+}
+
+// ConversationID returns the ID of the conversation of the chat message, as seen by the user.
+func (m *MkChatMessage) ConversationID(userID string) string {
+	if m.ToRoomId != nil {
+		return ChatRoomConversationIDPrefix + *m.ToRoomId
+	}
+	return ChatUserConversationIDPrefix + m.PeerID(userID)
+}
+
+func (m *MkChatMessage) ToStatus(server string) Status {
+	s := Status{
+		ID:               ChatStatusIDPrefix + m.ID,
+		CreatedAt:        m.CreatedAt,
+		Visibility:       StatusVisibilityDirect,
+		Emojis:           []CustomEmoji{},
+		MediaAttachments: []MediaAttachment{},
+		Mentions:         []StatusMention{},
+	}
+	// The chat of a one-to-one message depends on who is looking at it.
+	s.Url = utils.JoinURL(server, "/chat")
+	if m.ToRoomId != nil {
+		s.Url = utils.JoinURL(server, "/chat/room/", *m.ToRoomId)
+	}
+	s.Uri = s.Url
+	if m.Text != nil {
+		s.Content = *m.Text
+		if content, err := mfm.ToHtml(*m.Text, mfm.Option{
+			Url:            utils.JoinURL(server),
+			HashtagHandler: mfm.MastodonHashtagHandler,
+		}); err == nil {
+			s.Content = content
+		}
+	}
+	if m.FromUser != nil {
+		if a, err := m.FromUser.ToAccount(server); err == nil {
+			s.Account = a
+		}
+	}
+	if m.File != nil {
+		s.Sensitive = m.File.IsSensitive
+		s.MediaAttachments = append(s.MediaAttachments, m.File.ToMediaAttachment())
+	}
+	return s
+}
+
+// SetLocalEmojis sets the custom emojis of the status converted from the chat message,
+// chat messages are local, local are the emojis of the server.
+func (m *MkChatMessage) SetLocalEmojis(s *Status, local map[string]string) {
+	if m.Text != nil {
+		s.Emojis = CustomEmojis(local, *m.Text)
+	}
+	if m.FromUser != nil {
+		m.FromUser.SetLocalEmojis(&s.Account, local)
+	}
+}
+
+// ToConversation converts the last chat message of a chat to a conversation, as seen by the user.
+func (m *MkChatMessage) ToConversation(server, userID string) Conversation {
+	status := m.ToStatus(server)
+	c := Conversation{
+		ID:         m.ConversationID(userID),
+		Unread:     m.FromUserId != userID && !m.IsRead,
+		Accounts:   []Account{},
+		LastStatus: &status,
+	}
+	if peer := m.Peer(userID); peer != nil {
+		if a, err := peer.ToAccount(server); err == nil {
+			c.Accounts = append(c.Accounts, a)
+		}
+	}
+	return c
+}
+
+// ChatMessageID returns the chat message ID of a status ID, ok is false if the status is not backed by a chat message.
+func ChatMessageID(statusID string) (id string, ok bool) {
+	return strings.CutPrefix(statusID, ChatStatusIDPrefix)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "regexp"
+
 type MkEmoji struct {
 	Aliases  []string `json:"aliases"`
 	Name     string   `json:"name"`
@@ -19,3 +21,31 @@ func (e MkEmoji) ToCustomEmoji() CustomEmoji {
 	}
 	return r
 }
+
+var emojiShortcodeRegexp = regexp.MustCompile(`:([\w+-]+(?:@[\w.-]+)?):`)
+
+// CustomEmojis returns the custom emojis used in the texts, urls are the URLs of the emojis by shortcode.
+// Misskey sends them with the remote notes and users, the emojis of local ones are the emojis of the server.
+func CustomEmojis(urls map[string]string, texts ...string) []CustomEmoji {
+	emojis := []CustomEmoji{}
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, m := range emojiShortcodeRegexp.FindAllStringSubmatch(text, -1) {
+			shortcode := m[1]
+			if seen[shortcode] {
+				continue
+			}
+			seen[shortcode] = true
+			url, ok := urls[shortcode]
+			if !ok {
+				continue
+			}
+			emojis = append(emojis, CustomEmoji{
+				Shortcode: shortcode,
+				Url:       url,
+				StaticUrl: url,
+			})
+		}
+	}
+	return emojis
+}
@@ -1,6 +1,13 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
 	"github.com/gizmo-ds/misstodon/internal/mfm"
 	"github.com/gizmo-ds/misstodon/internal/utils"
 )
@@ -15,31 +22,39 @@ const (
 )
 
 type MkNote struct {
-	ID           string           `json:"id"`
-	CreatedAt    string           `json:"createdAt"`
-	ReplyID      *string          `json:"replyId"`
-	ThreadId     *string          `json:"threadId"`
-	Text         *string          `json:"text"`
-	Name         *string          `json:"name"`
-	Cw           *string          `json:"cw"`
-	UserId       string           `json:"userId"`
-	User         *MkUser          `json:"user"`
-	LocalOnly    bool             `json:"localOnly"`
-	Reply        *MkNote          `json:"reply"`
-	ReNote       *MkNote          `json:"renote"`
-	ReNoteId     *string          `json:"renoteId"`
-	ReNoteCount  int              `json:"renoteCount"`
-	RepliesCount int              `json:"repliesCount"`
-	Reactions    map[string]int   `json:"reactions"`
-	Visibility   MkNoteVisibility `json:"visibility"`
-	Uri          *string          `json:"uri"`
-	Url          *string          `json:"url"`
-	Score        int              `json:"score"`
-	FileIds      []string         `json:"fileIds"`
-	Files        []MkFile         `json:"files"`
-	Tags         []string         `json:"tags"`
-	MyReaction   string           `json:"myReaction"`
-	Poll         *MkPoll          `json:"poll"`
+	ID           string         `json:"id"`
+	CreatedAt    string         `json:"createdAt"`
+	UpdatedAt    *string        `json:"updatedAt"`
+	ReplyID      *string        `json:"replyId"`
+	ThreadId     *string        `json:"threadId"`
+	Text         *string        `json:"text"`
+	Name         *string        `json:"name"`
+	Cw           *string        `json:"cw"`
+	UserId       string         `json:"userId"`
+	User         *MkUser        `json:"user"`
+	LocalOnly    bool           `json:"localOnly"`
+	Reply        *MkNote        `json:"reply"`
+	ReNote       *MkNote        `json:"renote"`
+	ReNoteId     *string        `json:"renoteId"`
+	ReNoteCount  int            `json:"renoteCount"`
+	RepliesCount int            `json:"repliesCount"`
+	Reactions    map[string]int `json:"reactions"`
+	// ReactionEmojis are the URLs of the remote custom emojis of the reactions.
+	ReactionEmojis map[string]string `json:"reactionEmojis"`
+	// Emojis are the URLs of the custom emojis of a remote note.
+	Emojis     map[string]string `json:"emojis"`
+	Visibility MkNoteVisibility  `json:"visibility"`
+	// VisibleUserIds are the recipients of a specified-visibility note.
+	VisibleUserIds []string `json:"visibleUserIds"`
+	Mentions       []string `json:"mentions"`
+	Uri            *string  `json:"uri"`
+	Url            *string  `json:"url"`
+	Score          int      `json:"score"`
+	FileIds        []string `json:"fileIds"`
+	Files          []MkFile `json:"files"`
+	Tags           []string `json:"tags"`
+	MyReaction     string   `json:"myReaction"`
+	Poll           *MkPoll  `json:"poll"`
 }
 
 func (n *MkNote) ToStatus(server string) Status {
@@ -48,13 +63,24 @@ func (n *MkNote) ToStatus(server string) Status {
 		Url:              utils.JoinURL(server, "/notes/", n.ID),
 		Uri:              utils.JoinURL(server, "/notes/", n.ID),
 		CreatedAt:        n.CreatedAt,
-		Emojis:           []struct{}{},
+		EditedAt:         n.UpdatedAt,
+		Emojis:           n.CustomEmojis(nil),
 		MediaAttachments: []MediaAttachment{},
 		Mentions:         []StatusMention{},
 		ReBlogsCount:     n.ReNoteCount,
 		RepliesCount:     n.RepliesCount,
 		Favourited:       n.MyReaction != "",
 	}
+	// Remote notes keep their ActivityPub ID and their page on their own server.
+	if n.Uri != nil && *n.Uri != "" {
+		s.Uri = *n.Uri
+		s.Url = *n.Uri
+		if n.Url != nil && *n.Url != "" {
+			s.Url = *n.Url
+		}
+	}
+	s.EmojiReactions = n.EmojiReactions(nil)
+	s.Pleroma = &StatusPleroma{EmojiReactions: s.EmojiReactions}
 	s.FavouritesCount = func() int {
 		var count int
 		for _, r := range n.Reactions {
@@ -118,10 +144,131 @@ func (n *MkNote) ToStatus(server string) Status {
 	}
 	if n.ReNote != nil {
 		re := n.ReNote.ToStatus(server)
-		s.ReBlog = &re
+		if n.IsQuote() {
+			s.Quote = &Quote{State: QuoteStateAccepted, QuotedStatus: &re}
+			s.QuoteID = &re.ID
+			s.Pleroma.Quote, s.Pleroma.QuoteID, s.Pleroma.QuoteURL = &re, &re.ID, &re.Url
+			// Apps that do not know quotes show the link to the quoted status, as in Mastodon.
+			s.Content += fmt.Sprintf(`<p class="quote-inline">RE: <a href="%[1]s">%[1]s</a></p>`, html.EscapeString(re.Url))
+		} else {
+			s.ReBlog = &re
+		}
 	}
 	if n.Poll != nil {
 		s.Poll = n.Poll.ToPoll(n.ID)
+		s.Poll.Emojis = n.pollEmojis(nil)
 	}
 	return s
 }
+
+// SetLocalEmojis sets the custom emojis of the status converted from the note, and of its author,
+// that are emojis of the server. Misskey does not send them, local are the emojis of the server.
+func (n *MkNote) SetLocalEmojis(s *Status, local map[string]string) {
+	s.Emojis = n.CustomEmojis(local)
+	s.EmojiReactions = n.EmojiReactions(local)
+	if s.Pleroma != nil {
+		s.Pleroma.EmojiReactions = s.EmojiReactions
+	}
+	if s.Poll != nil && n.Poll != nil {
+		s.Poll.Emojis = n.pollEmojis(local)
+	}
+	if n.User != nil {
+		n.User.SetLocalEmojis(&s.Account, local)
+	}
+}
+
+func (n *MkNote) pollEmojis(local map[string]string) []CustomEmoji {
+	var choices []string
+	for _, c := range n.Poll.Choices {
+		choices = append(choices, c.Text)
+	}
+	return CustomEmojis(n.emojiUrls(local), choices...)
+}
+
+// CustomEmojis returns the custom emojis of the text of the note and of its reactions,
+// local are the emojis of the server.
+func (n *MkNote) CustomEmojis(local map[string]string) []CustomEmoji {
+	var texts []string
+	if n.Text != nil {
+		texts = append(texts, *n.Text)
+	}
+	if n.Cw != nil {
+		texts = append(texts, *n.Cw)
+	}
+	if n.Poll != nil {
+		for _, c := range n.Poll.Choices {
+			texts = append(texts, c.Text)
+		}
+	}
+	emojis := CustomEmojis(n.emojiUrls(local), texts...)
+	for _, r := range n.EmojiReactions(local) {
+		if r.Url == nil {
+			continue
+		}
+		exists := false
+		for _, e := range emojis {
+			exists = exists || e.Shortcode == r.Name
+		}
+		if !exists {
+			emojis = append(emojis, CustomEmoji{Shortcode: r.Name, Url: *r.Url, StaticUrl: *r.StaticUrl})
+		}
+	}
+	return emojis
+}
+
+// emojiUrls returns the URLs of the custom emojis of the note, the emojis Misskey sends
+// if its author is remote, the emojis of the server if it is local.
+func (n *MkNote) emojiUrls(local map[string]string) map[string]string {
+	if n.User != nil && n.User.Host != nil {
+		return n.Emojis
+	}
+	return local
+}
+
+// LinkUrl returns the first link of the text of the note, the one that is previewed.
+func (n *MkNote) LinkUrl() string {
+	if n.Text == nil {
+		return ""
+	}
+	if urls := mfm.Urls(*n.Text); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// IsQuote reports whether the note is a quote renote, a renote with its own content.
+func (n *MkNote) IsQuote() bool {
+	return n.ReNote != nil && (n.Text != nil || n.Cw != nil || n.ReplyID != nil || len(n.FileIds) > 0 || n.Poll != nil)
+}
+
+// Participants returns the sorted IDs of the author and the recipients of a specified-visibility note.
+func (n *MkNote) Participants() []string {
+	ids := append([]string{n.UserId}, n.VisibleUserIds...)
+	ids = append(ids, n.Mentions...)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// ConversationID returns the ID of the direct conversation of the note,
+// the notes between the same participants share their conversation.
+func (n *MkNote) ConversationID() string {
+	sum := sha256.Sum256([]byte(strings.Join(n.Participants(), ",")))
+	return hex.EncodeToString(sum[:10])
+}
+
+// ToConversation converts a specified-visibility note to a direct conversation
+// whose last status is the note, as seen by the user.
+// Accounts only holds the author, unless it is the user.
+func (n *MkNote) ToConversation(server, userID string) Conversation {
+	status := n.ToStatus(server)
+	c := Conversation{
+		ID:         n.ConversationID(),
+		Unread:     n.UserId != userID,
+		Accounts:   []Account{},
+		LastStatus: &status,
+	}
+	if n.User != nil && n.UserId != userID {
+		c.Accounts = append(c.Accounts, status.Account)
+	}
+	return c
+}
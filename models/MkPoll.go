@@ -23,7 +23,7 @@ func (p *MkPoll) ToPoll(noteID string) *Poll {
 		Multiple: p.Multiple,
 		Options:  []PollOption{},
 		OwnVotes: []int{},
-		Emojis:   []struct{}{},
+		Emojis:   []CustomEmoji{},
 	}
 	if p.ExpiresAt != nil {
 		poll.ExpiresAt = p.ExpiresAt
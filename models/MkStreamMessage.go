@@ -1,11 +1,73 @@
 package models
 
+import "encoding/json"
+
+type MkStreamMessageType = string
+
+const (
+	MkStreamMessageTypeChannel     MkStreamMessageType = "channel"
+	MkStreamMessageTypeNoteUpdated MkStreamMessageType = "noteUpdated"
+)
+
 type MkStreamMessage struct {
-	Type string `json:"type"`
+	Type MkStreamMessageType `json:"type"`
+	Body json.RawMessage     `json:"body"`
+}
+
+// MkStreamMessageBody is the body of a channel message or a noteUpdated message.
+// For channel messages ID is the connection ID, for noteUpdated it is the note ID.
+type MkStreamMessageBody struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
+}
+
+// ParseBody decodes the message body of channel and noteUpdated messages.
+func (m MkStreamMessage) ParseBody() (MkStreamMessageBody, error) {
+	var body MkStreamMessageBody
+	err := json.Unmarshal(m.Body, &body)
+	return body, err
+}
+
+// ToStreamEvent converts the message to a Mastodon streaming event,
+// ok is false if the message has no Mastodon equivalent.
+func (m MkStreamMessage) ToStreamEvent(server string) (event StreamEvent, ok bool) {
+	body, err := m.ParseBody()
+	if err != nil {
+		return
+	}
+	switch m.Type {
+	case MkStreamMessageTypeChannel:
+		switch body.Type {
+		case "notification":
+			var n MkNotification
+			if err = json.Unmarshal(body.Body, &n); err != nil {
+				return
+			}
+			notification, err := n.ToNotification(server)
+			if err != nil || notification.Type == NotificationTypeUnknown {
+				return
+			}
+			return newStreamEvent(StreamEventTypeNotification, notification)
+		case "note":
+			var note MkNote
+			if err = json.Unmarshal(body.Body, &note); err != nil {
+				return
+			}
+			return newStreamEvent(StreamEventTypeUpdate, note.ToStatus(server))
+		}
+	case MkStreamMessageTypeNoteUpdated:
+		if body.Type == "deleted" {
+			return StreamEvent{Event: StreamEventTypeDelete, Payload: body.ID}, true
+		}
+	}
+	return
 }
 
-func (m MkStreamMessage) ToStreamEvent() StreamEvent {
-	return StreamEvent{
-		Event: m.Type,
+func newStreamEvent(event StreamEventType, payload any) (StreamEvent, bool) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return StreamEvent{}, false
 	}
+	return StreamEvent{Event: event, Payload: string(data)}, true
 }
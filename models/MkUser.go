@@ -9,10 +9,13 @@ import (
 )
 
 type MkUser struct {
-	ID             string         `json:"id"`
-	Username       string         `json:"username"`
-	Name           string         `json:"name"`
-	Host           *string        `json:"host,omitempty"`
+	ID       string  `json:"id"`
+	Username string  `json:"username"`
+	Name     string  `json:"name"`
+	Host     *string `json:"host,omitempty"`
+	// Uri and Url are the ActivityPub ID and the profile page of a remote user.
+	Uri            *string        `json:"uri"`
+	Url            *string        `json:"url"`
 	Location       *string        `json:"location"`
 	Description    *string        `json:"description"`
 	IsBot          bool           `json:"isBot"`
@@ -32,6 +35,8 @@ type MkUser struct {
 	IsBlocking     bool           `json:"isBlocking"`
 	IsFollowing    bool           `json:"isFollowing"`
 	IsFollowed     bool           `json:"isFollowed"`
+	// Emojis are the URLs of the custom emojis of a remote user.
+	Emojis map[string]string `json:"emojis"`
 }
 
 type MkInstance struct {
@@ -46,18 +51,15 @@ type MkInstance struct {
 func (u *MkUser) ToAccount(server string) (Account, error) {
 	var info Account
 	var err error
-	host := server
-	if u.Host != nil {
-		host = *u.Host
-	}
 	info = Account{
 		ID:             u.ID,
 		Username:       u.Username,
-		Acct:           u.Username + "@" + host,
+		Acct:           u.Username,
 		DisplayName:    u.Name,
 		Locked:         u.IsLocked,
 		Bot:            u.IsBot,
-		Url:            "https://" + host + "/@" + u.Username,
+		Url:            utils.JoinURL(server, "/@", u.Username),
+		Uri:            utils.JoinURL(server, "/users/", u.ID),
 		Avatar:         u.AvatarUrl,
 		AvatarStatic:   u.AvatarUrl,
 		Header:         u.BannerUrl,
@@ -70,9 +72,24 @@ func (u *MkUser) ToAccount(server string) (Account, error) {
 		CreatedAt:      u.CreatedAt,
 		Limited:        &u.IsMuted,
 	}
+	// Remote users are known by their acct on their own server, as in Mastodon.
+	if u.Host != nil {
+		info.Acct = u.Username + "@" + *u.Host
+		info.Url = utils.JoinURL(*u.Host, "/@", u.Username)
+		info.Uri = info.Url
+		if u.Uri != nil && *u.Uri != "" {
+			info.Uri = *u.Uri
+		}
+		if u.Url != nil && *u.Url != "" {
+			info.Url = *u.Url
+		} else if u.Uri != nil && *u.Uri != "" {
+			info.Url = *u.Uri
+		}
+	}
 	if info.DisplayName == "" {
 		info.DisplayName = info.Username
 	}
+	info.Emojis = u.CustomEmojis(nil)
 	_lastStatusAt := u.UpdatedAt
 	if _lastStatusAt != nil {
 		lastStatusAt, err := time.Parse(time.RFC3339, *_lastStatusAt)
@@ -93,3 +110,30 @@ func (u *MkUser) ToAccount(server string) (Account, error) {
 	}
 	return info, nil
 }
+
+// CustomEmojis returns the custom emojis of the name, the description and the fields of the user,
+// local are the emojis of the server.
+func (u *MkUser) CustomEmojis(local map[string]string) []CustomEmoji {
+	texts := []string{u.Name}
+	if u.Description != nil {
+		texts = append(texts, *u.Description)
+	}
+	for _, f := range u.Fields {
+		texts = append(texts, f.Name, f.Value)
+	}
+	return CustomEmojis(u.emojiUrls(local), texts...)
+}
+
+// SetLocalEmojis sets the custom emojis of the account converted from the user, local are the emojis of the server.
+func (u *MkUser) SetLocalEmojis(a *Account, local map[string]string) {
+	a.Emojis = u.CustomEmojis(local)
+}
+
+// emojiUrls returns the URLs of the custom emojis of the user, the emojis Misskey sends
+// if the user is remote, the emojis of the server if it is local.
+func (u *MkUser) emojiUrls(local map[string]string) map[string]string {
+	if u.Host != nil {
+		return u.Emojis
+	}
+	return local
+}
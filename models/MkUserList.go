@@ -0,0 +1,24 @@
+package models
+
+type MkUserList struct {
+	ID        string   `json:"id"`
+	CreatedAt string   `json:"createdAt"`
+	Name      string   `json:"name"`
+	UserIds   []string `json:"userIds"`
+	IsPublic  bool     `json:"isPublic"`
+}
+
+// MkUserListMembership is a member of a user list.
+type MkUserListMembership struct {
+	ID     string  `json:"id"`
+	UserId string  `json:"userId"`
+	User   *MkUser `json:"user"`
+}
+
+func (l MkUserList) ToList() List {
+	return List{
+		ID:            l.ID,
+		Title:         l.Name,
+		RepliesPolicy: ListRepliesPolicyList,
+	}
+}
@@ -1,16 +1,16 @@
 package models
 
 type Poll struct {
-	ID          string       `json:"id"`
-	ExpiresAt   *string      `json:"expires_at"`
-	Expired     bool         `json:"expired"`
-	Multiple    bool         `json:"multiple"`
-	VotesCount  int          `json:"votes_count"`
-	VotersCount *int         `json:"voters_count"`
-	Voted       *bool        `json:"voted"`
-	OwnVotes    []int        `json:"own_votes"`
-	Options     []PollOption `json:"options"`
-	Emojis      []struct{}   `json:"emojis"`
+	ID          string        `json:"id"`
+	ExpiresAt   *string       `json:"expires_at"`
+	Expired     bool          `json:"expired"`
+	Multiple    bool          `json:"multiple"`
+	VotesCount  int           `json:"votes_count"`
+	VotersCount *int          `json:"voters_count"`
+	Voted       *bool         `json:"voted"`
+	OwnVotes    []int         `json:"own_votes"`
+	Options     []PollOption  `json:"options"`
+	Emojis      []CustomEmoji `json:"emojis"`
 }
 
 type PollOption struct {
@@ -15,6 +15,12 @@ const (
 	StatusVisibilityDirect StatusVisibility = "direct"
 )
 
+type QuoteState string
+
+const (
+	QuoteStateAccepted QuoteState = "accepted"
+)
+
 type (
 	Status struct {
 		ID                 string            `json:"id"`
@@ -26,8 +32,8 @@ type (
 		EditedAt           *string           `json:"edited_at"`
 		Content            string            `json:"content"`
 		MediaAttachments   []MediaAttachment `json:"media_attachments"`
-		Card               *struct{}         `json:"card"`
-		Emojis             []struct{}        `json:"emojis"`
+		Card               *Card             `json:"card"`
+		Emojis             []CustomEmoji     `json:"emojis"`
 		Account            Account           `json:"account"`
 		Sensitive          bool              `json:"sensitive"`
 		SpoilerText        string            `json:"spoiler_text"`
@@ -44,6 +50,25 @@ type (
 		ReBlogged          bool              `json:"reblogged"`
 		ReBlogsCount       int               `json:"reblogs_count"`
 		RepliesCount       int               `json:"replies_count"`
+		Filtered           []FilterResult    `json:"filtered,omitempty"`
+		Quote              *Quote            `json:"quote,omitempty"`
+		// QuoteID is the ID of the quoted status, as in Fedibird.
+		QuoteID *string        `json:"quote_id,omitempty"`
+		Pleroma *StatusPleroma `json:"pleroma,omitempty"`
+		// EmojiReactions are the emoji reactions of the status, as in Fedibird.
+		EmojiReactions []EmojiReaction `json:"emoji_reactions,omitempty"`
+	}
+	// Quote is the Mastodon 4.4 quote of a status.
+	Quote struct {
+		State        QuoteState `json:"state"`
+		QuotedStatus *Status    `json:"quoted_status"`
+	}
+	// StatusPleroma holds the Pleroma extensions of a status.
+	StatusPleroma struct {
+		EmojiReactions []EmojiReaction `json:"emoji_reactions"`
+		Quote          *Status         `json:"quote,omitempty"`
+		QuoteID        *string         `json:"quote_id,omitempty"`
+		QuoteURL       *string         `json:"quote_url,omitempty"`
 	}
 	StatusTag struct {
 		Name string `json:"name"`
@@ -56,22 +81,31 @@ type (
 		Acct     string `json:"acct"`
 	}
 	ScheduledStatus struct {
-		ID          string    `json:"id"`
-		ScheduledAt time.Time `json:"scheduled_at"`
-		Params      struct {
-			Text          string           `json:"text"`
-			Poll          *string          `json:"poll"`
-			MediaIDs      any              `json:"media_ids"` // []string | string | null
-			Sensitive     *bool            `json:"sensitive"`
-			SpoilerText   *string          `json:"spoiler_text"`
-			Visibility    StatusVisibility `json:"visibility"`
-			InReplyToID   *int             `json:"in_reply_to_id"` // ID of the Status that will be replied to.
-			Language      *string          `json:"language"`
-			ApplicationID int              `json:"application_id"`
-			ScheduledAt   any              `json:"scheduled_at"`
-			Idempotency   *string          `json:"idempotency"`
-			WithRateLimit bool             `json:"with_rate_limit"`
-		} `json:"params"`
-		MediaAttachments []MediaAttachment `json:"media_attachments"`
+		ID               string                `json:"id"`
+		ScheduledAt      time.Time             `json:"scheduled_at"`
+		Params           ScheduledStatusParams `json:"params"`
+		MediaAttachments []MediaAttachment     `json:"media_attachments"`
+	}
+	ScheduledStatusParams struct {
+		Text           string                     `json:"text"`
+		Poll           *ScheduledStatusPollParams `json:"poll"`
+		MediaIDs       []string                   `json:"media_ids"`
+		Sensitive      *bool                      `json:"sensitive"`
+		SpoilerText    *string                    `json:"spoiler_text"`
+		Visibility     StatusVisibility           `json:"visibility"`
+		InReplyToID    *string                    `json:"in_reply_to_id"` // ID of the Status that will be replied to.
+		QuotedStatusID *string                    `json:"quoted_status_id"`
+		ChannelID      *string                    `json:"channel_id"` // ID of the Misskey channel that will be posted to.
+		Language       *string                    `json:"language"`
+		ApplicationID  int                        `json:"application_id"`
+		ScheduledAt    *time.Time                 `json:"scheduled_at"`
+		Idempotency    *string                    `json:"idempotency"`
+		WithRateLimit  bool                       `json:"with_rate_limit"`
+	}
+	ScheduledStatusPollParams struct {
+		Options    []string `json:"options"`
+		ExpiresIn  int      `json:"expires_in"`
+		Multiple   bool     `json:"multiple"`
+		HideTotals bool     `json:"hide_totals"`
 	}
 )
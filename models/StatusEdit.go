@@ -0,0 +1,50 @@
+package models
+
+type (
+	// StatusEdit is a revision of a status.
+	StatusEdit struct {
+		Content          string            `json:"content"`
+		SpoilerText      string            `json:"spoiler_text"`
+		Sensitive        bool              `json:"sensitive"`
+		CreatedAt        string            `json:"created_at"`
+		Account          Account           `json:"account"`
+		Poll             *StatusEditPoll   `json:"poll"`
+		MediaAttachments []MediaAttachment `json:"media_attachments"`
+		Emojis           []CustomEmoji     `json:"emojis"`
+	}
+	StatusEditPoll struct {
+		Options []StatusEditPollOption `json:"options"`
+	}
+	StatusEditPollOption struct {
+		Title string `json:"title"`
+	}
+	// StatusSource is what the user wrote, for editing the status.
+	StatusSource struct {
+		ID          string `json:"id"`
+		Text        string `json:"text"`
+		SpoilerText string `json:"spoiler_text"`
+	}
+)
+
+// ToStatusEdit returns the current revision of the status.
+func (s Status) ToStatusEdit() StatusEdit {
+	e := StatusEdit{
+		Content:          s.Content,
+		SpoilerText:      s.SpoilerText,
+		Sensitive:        s.Sensitive,
+		CreatedAt:        s.CreatedAt,
+		Account:          s.Account,
+		MediaAttachments: s.MediaAttachments,
+		Emojis:           s.Emojis,
+	}
+	if s.EditedAt != nil && *s.EditedAt != "" {
+		e.CreatedAt = *s.EditedAt
+	}
+	if s.Poll != nil {
+		e.Poll = &StatusEditPoll{Options: []StatusEditPollOption{}}
+		for _, o := range s.Poll.Options {
+			e.Poll.Options = append(e.Poll.Options, StatusEditPollOption{Title: o.Title})
+		}
+	}
+	return e
+}
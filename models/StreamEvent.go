@@ -1,5 +1,17 @@
 package models
 
+type StreamEventType = string
+
+const (
+	StreamEventTypeUpdate       StreamEventType = "update"
+	StreamEventTypeDelete       StreamEventType = "delete"
+	StreamEventTypeNotification StreamEventType = "notification"
+	StreamEventTypeConversation StreamEventType = "conversation"
+)
+
 type StreamEvent struct {
-	Event string `json:"event"`
+	Stream []string        `json:"stream"`
+	Event  StreamEventType `json:"event"`
+	// Payload is a JSON-encoded Status, Notification or Conversation, or a bare status ID for delete events.
+	Payload string `json:"payload"`
 }
@@ -38,7 +38,7 @@ func AccountsLookup(ctx Context, acct string) (models.Account, error) {
 	if resp.StatusCode() != http.StatusOK {
 		return info, ErrNotFound
 	}
-	return result.ToAccount(ctx.ProxyServer())
+	return userToAccount(ctx, &result)
 }
 
 func AccountsStatuses(
@@ -64,7 +64,7 @@ func AccountsStatuses(
 	if resp.StatusCode() != http.StatusOK {
 		return nil, errors.New("failed to get statuses")
 	}
-	statuses := lo.Map(notes, func(note models.MkNote, _i int) models.Status { return note.ToStatus(ctx.ProxyServer()) })
+	statuses := notesToStatuses(ctx, notes)
 	return statuses, nil
 }
 
@@ -82,7 +82,7 @@ func VerifyCredentials(ctx Context) (models.CredentialAccount, error) {
 	if resp.StatusCode() != 200 {
 		return info, errors.New("failed to verify credentials")
 	}
-	account, err = result.ToAccount(ctx.ProxyServer())
+	account, err = userToAccount(ctx, &result)
 	if err != nil {
 		return info, err
 	}
@@ -162,7 +162,7 @@ func UpdateCredentials(ctx Context,
 	if resp.StatusCode() != 200 {
 		return info, errors.New("failed to update credentials")
 	}
-	account, err := result.ToAccount(ctx.ProxyServer())
+	account, err := userToAccount(ctx, &result)
 	if err != nil {
 		return info, err
 	}
@@ -200,7 +200,7 @@ func AccountFollowRequests(ctx Context,
 	}
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.Follower.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.Follower); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -278,7 +278,7 @@ func AccountFollowers(ctx Context, userID string,
 
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.Follower.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.Follower); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -315,7 +315,7 @@ func AccountFollowing(ctx Context,
 
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.Followee.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.Followee); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -416,7 +416,7 @@ func AccountsGet(ctx Context, userID string) (models.Account, error) {
 	if resp.StatusCode() != http.StatusOK {
 		return info, ErrNotFound
 	}
-	return result.ToAccount(ctx.ProxyServer())
+	return userToAccount(ctx, &result)
 }
 
 func AccountFavourites(ctx Context,
@@ -447,5 +447,37 @@ func AccountFavourites(ctx Context,
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return lo.Map(result, func(r reactionsResult, i int) models.Status { return r.Note.ToStatus(ctx.ProxyServer()) }), nil
+	return notesToStatuses(ctx, lo.Map(result, func(r reactionsResult, _ int) models.MkNote { return r.Note })), nil
+}
+
+// usersShow returns the accounts of the user IDs, the users that cannot be found are left out.
+func usersShow(ctx Context, userIDs []string) ([]models.Account, error) {
+	var result []models.MkUser
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"userIds": userIDs})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/show"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var accounts []models.Account
+	for _, u := range result {
+		if a, err := userToAccount(ctx, &u); err == nil {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts, nil
+}
+
+// userToAccount converts the user to an account with the custom emojis of the server.
+func userToAccount(ctx Context, u *models.MkUser) (models.Account, error) {
+	a, err := u.ToAccount(ctx.ProxyServer())
+	if err != nil {
+		return a, err
+	}
+	u.SetLocalEmojis(&a, localEmojis(ctx.ProxyServer()))
+	return a, nil
 }
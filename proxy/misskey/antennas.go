@@ -0,0 +1,64 @@
+package misskey
+
+import (
+	"net/http"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// Misskey antennas have no Mastodon equivalent, they are exposed as read-only lists.
+
+func antennas(ctx Context) ([]models.MkAntenna, error) {
+	var result []models.MkAntenna
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/antennas/list"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func antennaShow(ctx Context, id string) (models.MkAntenna, error) {
+	var result models.MkAntenna
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"antennaId": id})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/antennas/show"))
+	if err != nil {
+		return result, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_ANTENNA"); err != nil {
+		return result, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func antennaNotes(ctx Context, id string,
+	limit int, maxId, minId string) ([]models.Status, error) {
+	body := makeBody(ctx, utils.Map{"antennaId": id, "limit": limit})
+	if minId != "" {
+		body["sinceId"] = minId
+	}
+	if maxId != "" {
+		body["untilId"] = maxId
+	}
+	var result []models.MkNote
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/antennas/notes"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_ANTENNA"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return notesToStatuses(ctx, result), nil
+}
@@ -60,7 +60,7 @@ func BlocksList(ctx Context, limit int, sinceID, maxID string) ([]models.Account
 	}
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.Blockee.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.Blockee); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -0,0 +1,122 @@
+package misskey
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// cardCacheTTL is how long the preview of a link is kept,
+	// cardMissCacheTTL how long a link without a preview is not fetched again.
+	cardCacheTTL     = 24 * time.Hour
+	cardMissCacheTTL = time.Hour
+	// cardFetchTimeout limits the fetch of a preview.
+	cardFetchTimeout       = 10 * time.Second
+	cardFetchesConcurrency = 4
+)
+
+var (
+	cardFetchesMu sync.Mutex
+	// cardFetches are the previews being fetched, a link is fetched once for all the requests.
+	cardFetches    = make(map[string]bool)
+	cardFetchesSem = make(chan struct{}, cardFetchesConcurrency)
+)
+
+func cardKey(server, link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return storage.Key(server, "cards", hex.EncodeToString(sum[:]))
+}
+
+// cardCached returns the cached preview of the link, ok is true if the link
+// has been fetched, the card is nil if it has no preview.
+func cardCached(server, link string) (card *models.Card, ok bool) {
+	value, err := storage.Default.Get(cardKey(server, link))
+	if err != nil {
+		return nil, false
+	}
+	if json.Unmarshal([]byte(value), &card) != nil {
+		return nil, false
+	}
+	return card, true
+}
+
+// linkCards returns the cached previews of the links. The links that have not been
+// fetched yet are fetched in the background, so their previews are there for the next requests.
+func linkCards(server string, links []string) map[string]*models.Card {
+	cards := make(map[string]*models.Card)
+	for _, link := range links {
+		if _, ok := cards[link]; ok {
+			continue
+		}
+		if card, ok := cardCached(server, link); ok {
+			cards[link] = card
+			continue
+		}
+		cardFetch(server, link)
+	}
+	return cards
+}
+
+// cardFetch fetches and caches the preview of the link in the background,
+// unless it is already being fetched.
+func cardFetch(server, link string) {
+	key := cardKey(server, link)
+	cardFetchesMu.Lock()
+	defer cardFetchesMu.Unlock()
+	if cardFetches[key] {
+		return
+	}
+	cardFetches[key] = true
+	go func() {
+		cardFetchesSem <- struct{}{}
+		defer func() {
+			<-cardFetchesSem
+			cardFetchesMu.Lock()
+			delete(cardFetches, key)
+			cardFetchesMu.Unlock()
+		}()
+		value, ttl := "null", cardMissCacheTTL
+		card, err := linkCard(server, link)
+		if err != nil {
+			log.Debug().Err(err).Str("url", link).Msg("Failed to fetch the link preview")
+		} else if data, err := json.Marshal(card); err == nil {
+			value, ttl = string(data), cardCacheTTL
+		}
+		if err = storage.Default.Set(key, value, ttl); err != nil {
+			log.Warn().Err(err).Msg("Failed to cache the link preview")
+		}
+	}()
+}
+
+// linkCard fetches the preview of the link with the summary proxy of Misskey.
+func linkCard(server, link string) (models.Card, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cardFetchTimeout)
+	defer cancel()
+	var summary models.MkUrlSummary
+	resp, err := client.R().
+		SetContext(ctx).
+		SetQueryParam("url", link).
+		SetResult(&summary).
+		Get(utils.JoinURL(server, "/url"))
+	if err != nil {
+		return models.Card{}, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return models.Card{}, errors.WithStack(err)
+	}
+	if summary.Title == nil || *summary.Title == "" {
+		return models.Card{}, errors.New("the page has no title")
+	}
+	return summary.ToCard(link), nil
+}
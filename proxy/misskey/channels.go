@@ -0,0 +1,67 @@
+package misskey
+
+import (
+	"net/http"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// Followed Misskey channels are exposed as read-only lists.
+
+// channelFollowedLimit is the maximum number of followed channels listed.
+const channelFollowedLimit = 100
+
+func channelsFollowed(ctx Context) ([]models.MkChannel, error) {
+	var result []models.MkChannel
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"limit": channelFollowedLimit})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/channels/followed"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func channelShow(ctx Context, id string) (models.MkChannel, error) {
+	var result models.MkChannel
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"channelId": id})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/channels/show"))
+	if err != nil {
+		return result, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_CHANNEL"); err != nil {
+		return result, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func channelTimeline(ctx Context, id string,
+	limit int, maxId, minId string) ([]models.Status, error) {
+	body := makeBody(ctx, utils.Map{"channelId": id, "limit": limit})
+	if minId != "" {
+		body["sinceId"] = minId
+	}
+	if maxId != "" {
+		body["untilId"] = maxId
+	}
+	var result []models.MkNote
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/channels/timeline"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_CHANNEL"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return notesToStatuses(ctx, result), nil
+}
@@ -0,0 +1,228 @@
+package misskey
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// chatHistoryLimit is how many chats the conversations are built from, the most Misskey returns.
+const chatHistoryLimit = 100
+
+// chatContextLimit is how many messages around a chat message are returned as its context.
+const chatContextLimit = 40
+
+// ErrChatReplyEmpty is returned for a chat reply with neither text, once the leading mentions are removed, nor media.
+var ErrChatReplyEmpty = errors.New("Validation failed: Text can't be blank")
+
+// chatNotFoundCodes are the error codes of a missing chat or message.
+var chatNotFoundCodes = []string{"NO_SUCH_MESSAGE", "NO_SUCH_USER", "NO_SUCH_ROOM"}
+
+// chatHistory returns the last message of each chat of the user, one-to-one chats and rooms.
+// It returns nothing if the server has no chat, or if the user is not allowed to use it.
+func chatHistory(ctx Context) ([]models.MkChatMessage, error) {
+	var messages []models.MkChatMessage
+	for _, room := range []bool{false, true} {
+		var result []models.MkChatMessage
+		resp, err := client.R().
+			SetBody(makeBody(ctx, utils.Map{"limit": chatHistoryLimit, "room": room})).
+			SetResult(&result).
+			Post(utils.JoinURL(ctx.ProxyServer(), "/api/chat/history"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = isucceed(resp, http.StatusOK); err != nil {
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) {
+				return nil, nil
+			}
+			return nil, errors.WithStack(err)
+		}
+		messages = append(messages, result...)
+	}
+	return messages, nil
+}
+
+func chatMessageShow(ctx Context, id string) (models.MkChatMessage, error) {
+	var message models.MkChatMessage
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"messageId": id})).
+		SetResult(&message).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/chat/messages/show"))
+	if err != nil {
+		return message, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, chatNotFoundCodes...); err != nil {
+		return message, errors.WithStack(err)
+	}
+	return message, nil
+}
+
+// chatTimeline returns the messages of the chat of the conversation, newest first.
+// Misskey marks the messages as read when they are fetched.
+func chatTimeline(ctx Context, conversationID string, limit int, sinceID, untilID string) ([]models.MkChatMessage, error) {
+	body := makeBody(ctx, utils.Map{"limit": limit})
+	var endpoint string
+	if id, ok := strings.CutPrefix(conversationID, models.ChatRoomConversationIDPrefix); ok {
+		body["roomId"] = id
+		endpoint = "/api/chat/messages/room-timeline"
+	} else if id, ok := strings.CutPrefix(conversationID, models.ChatUserConversationIDPrefix); ok {
+		body["userId"] = id
+		endpoint = "/api/chat/messages/user-timeline"
+	} else {
+		return nil, ErrNotFound
+	}
+	if sinceID != "" {
+		body["sinceId"] = sinceID
+	}
+	if untilID != "" {
+		body["untilId"] = untilID
+	}
+	var messages []models.MkChatMessage
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&messages).
+		Post(utils.JoinURL(ctx.ProxyServer(), endpoint))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, chatNotFoundCodes...); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return messages, nil
+}
+
+func chatStatus(ctx Context, id string) (models.Status, error) {
+	message, err := chatMessageShow(ctx, id)
+	if err != nil {
+		return models.Status{}, err
+	}
+	return chatMessageToStatus(ctx, &message), nil
+}
+
+// chatMessageToStatus converts the chat message to a status with the custom emojis of the server.
+func chatMessageToStatus(ctx Context, m *models.MkChatMessage) models.Status {
+	s := m.ToStatus(ctx.ProxyServer())
+	m.SetLocalEmojis(&s, localEmojis(ctx.ProxyServer()))
+	return s
+}
+
+// chatMessageToConversation converts the last chat message of a chat to a conversation
+// with the custom emojis of the server.
+func chatMessageToConversation(ctx Context, m *models.MkChatMessage, userID string) models.Conversation {
+	c := m.ToConversation(ctx.ProxyServer(), userID)
+	emojis := localEmojis(ctx.ProxyServer())
+	m.SetLocalEmojis(c.LastStatus, emojis)
+	if peer := m.Peer(userID); peer != nil && len(c.Accounts) > 0 {
+		peer.SetLocalEmojis(&c.Accounts[0], emojis)
+	}
+	return c
+}
+
+func chatMessageDelete(ctx Context, id string) error {
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"messageId": id})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/chat/messages/delete"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, chatNotFoundCodes...); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// chatContext returns the messages of the chat before and after the message,
+// so Mastodon apps show the chat as the thread of the message.
+func chatContext(ctx Context, id string) (map[string]any, error) {
+	if ctx.UserID() == nil {
+		return nil, ErrUnauthorized
+	}
+	message, err := chatMessageShow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	conversationID := message.ConversationID(*ctx.UserID())
+	before, err := chatTimeline(ctx, conversationID, chatContextLimit, "", message.ID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := chatTimeline(ctx, conversationID, chatContextLimit, message.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	ancestors := []models.Status{}
+	for i := len(before) - 1; i >= 0; i-- {
+		ancestors = append(ancestors, chatMessageToStatus(ctx, &before[i]))
+	}
+	descendants := []models.Status{}
+	for _, m := range after {
+		descendants = append(descendants, chatMessageToStatus(ctx, &m))
+	}
+	// The order of the messages after sinceId depends on the Misskey version.
+	if len(descendants) > 1 && descendants[0].ID > descendants[len(descendants)-1].ID {
+		for i, j := 0, len(descendants)-1; i < j; i, j = i+1, j-1 {
+			descendants[i], descendants[j] = descendants[j], descendants[i]
+		}
+	}
+	return map[string]any{
+		"ancestors":   ancestors,
+		"descendants": descendants,
+	}, nil
+}
+
+// leadingMentionsRegexp matches the mentions Mastodon apps put at the start of replies.
+var leadingMentionsRegexp = regexp.MustCompile(`^(\s*@[\w.-]+(@[\w.-]+)?)+\s*`)
+
+// chatReply posts the reply to a chat message into its chat, a message is posted for each file
+// after the first, as a chat message has a single file.
+func chatReply(ctx Context, replyToID string, text string, fileIDs []string) (models.Status, error) {
+	if ctx.UserID() == nil {
+		return models.Status{}, ErrUnauthorized
+	}
+	text = strings.TrimSpace(leadingMentionsRegexp.ReplaceAllString(text, ""))
+	if text == "" && len(fileIDs) == 0 {
+		return models.Status{}, ErrChatReplyEmpty
+	}
+	replyTo, err := chatMessageShow(ctx, replyToID)
+	if err != nil {
+		return models.Status{}, err
+	}
+	body := utils.Map{}
+	endpoint := "/api/chat/messages/create-to-user"
+	if replyTo.ToRoomId != nil {
+		body["toRoomId"] = *replyTo.ToRoomId
+		endpoint = "/api/chat/messages/create-to-room"
+	} else {
+		body["toUserId"] = replyTo.PeerID(*ctx.UserID())
+	}
+
+	var first *models.MkChatMessage
+	for i := 0; i == 0 || i < len(fileIDs); i++ {
+		b := makeBody(ctx, body)
+		if i == 0 && text != "" {
+			b["text"] = text
+		}
+		if i < len(fileIDs) {
+			b["fileId"] = fileIDs[i]
+		}
+		var message models.MkChatMessage
+		resp, err := client.R().
+			SetBody(b).
+			SetResult(&message).
+			Post(utils.JoinURL(ctx.ProxyServer(), endpoint))
+		if err != nil {
+			return models.Status{}, errors.WithStack(err)
+		}
+		if err = isucceedNotFound(resp, chatNotFoundCodes...); err != nil {
+			return models.Status{}, errors.WithStack(err)
+		}
+		if first == nil {
+			first = &message
+		}
+	}
+	return chatMessageToStatus(ctx, first), nil
+}
@@ -0,0 +1,367 @@
+package misskey
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// conversationsNoteLimit is how many received and sent direct notes are read at a time.
+const conversationsNoteLimit = 100
+
+// conversationsMaxPages bounds how many pages of direct notes are read to fill a page of conversations.
+const conversationsMaxPages = 10
+
+// conversationsMu serializes the updates of the conversation states.
+var conversationsMu sync.Mutex
+
+// conversationState is what Misskey does not know about a conversation.
+type conversationState struct {
+	// LastReadID is the ID of the last status the user has read.
+	LastReadID string `json:"last_read_id"`
+	// DeletedID is the ID of the last status when the user removed the conversation,
+	// it comes back with the next status.
+	DeletedID string `json:"deleted_id"`
+	// Participants are the users of a conversation of notes, its ID is derived from them.
+	Participants []string `json:"participants,omitempty"`
+	// LastStatusID is the ID of the last status of a conversation of notes the user has been shown.
+	LastStatusID string `json:"last_status_id,omitempty"`
+}
+
+func conversationStateGet(ctx Context, id string) (state conversationState, err error) {
+	key, err := storageKey(ctx, "conversations", id)
+	if err != nil {
+		return
+	}
+	value, err := storage.Default.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = nil
+		}
+		return
+	}
+	err = json.Unmarshal([]byte(value), &state)
+	return
+}
+
+func conversationStateSet(ctx Context, id string, state conversationState) error {
+	key, err := storageKey(ctx, "conversations", id)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return storage.Default.Set(key, string(data), 0)
+}
+
+// directNotes returns the specified-visibility notes the user has received and sent, newest first.
+// more reports whether older notes are left after the page.
+func directNotes(ctx Context, maxID, sinceID, minID string) (notes []models.MkNote, more bool, err error) {
+	page := utils.Map{"limit": conversationsNoteLimit}
+	if maxID != "" {
+		page["untilId"] = maxID
+	}
+	if minID != "" {
+		page["sinceId"] = minID
+	} else if sinceID != "" {
+		page["sinceId"] = sinceID
+	}
+	var received, sent []models.MkNote
+	body := makeBody(ctx, utils.Map{"visibility": models.MkNoteVisibilitySpecif})
+	for k, v := range page {
+		body[k] = v
+	}
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&received).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/mentions"))
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	body = makeBody(ctx, utils.Map{"userId": *ctx.UserID(), "includeReplies": true})
+	for k, v := range page {
+		body[k] = v
+	}
+	resp, err = client.R().
+		SetBody(body).
+		SetResult(&sent).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/notes"))
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+
+	// A full source may have older notes than the other one, the page stops
+	// at its last note so the notes of both sources below it are on the next page.
+	var cutoff string
+	if sinceID == "" && minID == "" {
+		for _, source := range [][]models.MkNote{received, sent} {
+			if len(source) < conversationsNoteLimit {
+				continue
+			}
+			more = true
+			last := source[0].ID
+			for _, n := range source {
+				last = min(last, n.ID)
+			}
+			cutoff = max(cutoff, last)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, n := range append(received, sent...) {
+		if n.Visibility != models.MkNoteVisibilitySpecif || seen[n.ID] || n.ID < cutoff {
+			continue
+		}
+		seen[n.ID] = true
+		notes = append(notes, n)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID > notes[j].ID })
+	return notes, more, nil
+}
+
+// conversationShown records the last status of the conversation of the note the user is shown,
+// and its participants so the conversation can be found from its ID.
+func conversationShown(ctx Context, n *models.MkNote) (conversationState, error) {
+	conversationsMu.Lock()
+	defer conversationsMu.Unlock()
+	id := n.ConversationID()
+	state, err := conversationStateGet(ctx, id)
+	if err != nil || state.LastStatusID >= n.ID {
+		return state, err
+	}
+	state.Participants = n.Participants()
+	state.LastStatusID = n.ID
+	return state, conversationStateSet(ctx, id, state)
+}
+
+// conversations groups the direct notes by participants, the conversations are ordered
+// by their last status, newest first. The notes are read until limit conversations are found,
+// a page starting at maxID leaves out the conversations that were on the previous pages.
+func conversations(ctx Context, limit int, maxID, sinceID, minID string) ([]models.Conversation, error) {
+	// The user ID is checked before it is used to find the sent notes.
+	if _, err := storageKey(ctx); err != nil {
+		return nil, err
+	}
+	userID := *ctx.UserID()
+	var result []models.Conversation
+	var lastNotes []*models.MkNote
+	participants := make(map[string][]string)
+	var userIDs []string
+	cursor := maxID
+	for page := 0; page < conversationsMaxPages && len(result) < limit; page++ {
+		notes, more, err := directNotes(ctx, cursor, sinceID, minID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range notes {
+			n := &notes[i]
+			id := n.ConversationID()
+			if _, ok := participants[id]; ok {
+				continue
+			}
+			participants[id] = nil
+			state, err := conversationStateGet(ctx, id)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if state.DeletedID != "" && n.ID <= state.DeletedID {
+				continue
+			}
+			if maxID != "" && state.LastStatusID > n.ID {
+				// The conversation has a newer status, it was on a previous page.
+				continue
+			}
+			if state, err = conversationShown(ctx, n); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			c := n.ToConversation(ctx.ProxyServer(), userID)
+			c.Unread = n.UserId != userID && n.ID > state.LastReadID
+			result = append(result, c)
+			lastNotes = append(lastNotes, n)
+			for _, p := range n.Participants() {
+				if p != userID {
+					participants[id] = append(participants[id], p)
+					if !utils.Contains(userIDs, p) {
+						userIDs = append(userIDs, p)
+					}
+				}
+			}
+			if len(result) == limit {
+				break
+			}
+		}
+		if !more || len(notes) == 0 {
+			break
+		}
+		cursor = notes[len(notes)-1].ID
+	}
+	var lastStatuses []*models.Status
+	for i := range result {
+		lastStatuses = append(lastStatuses, result[i].LastStatus)
+	}
+	completeStatuses(ctx, lastStatuses, lastNotes)
+	if len(userIDs) > 0 {
+		accounts, err := usersShow(ctx, userIDs)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]models.Account)
+		for _, a := range accounts {
+			byID[a.ID] = a
+		}
+		for i := range result {
+			result[i].Accounts = []models.Account{}
+			for _, p := range participants[result[i].ID] {
+				if a, ok := byID[p]; ok {
+					result[i].Accounts = append(result[i].Accounts, a)
+				}
+			}
+		}
+	}
+
+	// The chats have no pages, they are only on the first one.
+	if maxID != "" {
+		return result, nil
+	}
+	messages, err := chatHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range messages {
+		if (sinceID != "" && m.ID <= sinceID) || (minID != "" && m.ID <= minID) {
+			continue
+		}
+		c := chatMessageToConversation(ctx, &m, userID)
+		state, err := conversationStateGet(ctx, c.ID)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if state.DeletedID != "" && c.LastStatus.ID <= state.DeletedID {
+			continue
+		}
+		c.Unread = c.Unread && c.LastStatus.ID > state.LastReadID
+		result = append(result, c)
+	}
+	// The statuses of the chats do not share the IDs of the notes, they are ordered by date.
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastStatus.CreatedAt > result[j].LastStatus.CreatedAt
+	})
+	return result, nil
+}
+
+// Conversations returns the direct conversations of the user, built from the
+// specified-visibility notes the user has recently received and sent, and from the Misskey chats.
+func Conversations(ctx Context, limit int, maxID, sinceID, minID string) ([]models.Conversation, error) {
+	result, err := conversations(ctx, limit, maxID, sinceID, minID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(result) > limit {
+		if minID != "" {
+			// min_id pages forward, so the conversations next to it are kept.
+			result = result[len(result)-limit:]
+		} else {
+			result = result[:limit]
+		}
+	}
+	return result, nil
+}
+
+// conversationFind returns the conversation of the ID. A chat is found from the ID of its user or room,
+// fetching it marks its messages as read in Misskey. A conversation of notes is found from its
+// last status recorded when it was shown to the user.
+func conversationFind(ctx Context, id string) (models.Conversation, error) {
+	if _, err := storageKey(ctx); err != nil {
+		return models.Conversation{}, err
+	}
+	userID := *ctx.UserID()
+	if strings.HasPrefix(id, models.ChatUserConversationIDPrefix) || strings.HasPrefix(id, models.ChatRoomConversationIDPrefix) {
+		messages, err := chatTimeline(ctx, id, 1, "", "")
+		if err != nil {
+			return models.Conversation{}, err
+		}
+		if len(messages) == 0 {
+			return models.Conversation{}, ErrNotFound
+		}
+		return chatMessageToConversation(ctx, &messages[0], userID), nil
+	}
+	state, err := conversationStateGet(ctx, id)
+	if err != nil {
+		return models.Conversation{}, err
+	}
+	if state.LastStatusID == "" {
+		return models.Conversation{}, ErrNotFound
+	}
+	note, err := noteShow(ctx, state.LastStatusID)
+	if err != nil {
+		return models.Conversation{}, err
+	}
+	c := note.ToConversation(ctx.ProxyServer(), userID)
+	c.Unread = c.Unread && note.ID > state.LastReadID
+	completeStatuses(ctx, []*models.Status{c.LastStatus}, []*models.MkNote{&note})
+	var others []string
+	for _, p := range state.Participants {
+		if p != userID {
+			others = append(others, p)
+		}
+	}
+	if len(others) > 0 {
+		accounts, err := usersShow(ctx, others)
+		if err != nil {
+			return models.Conversation{}, err
+		}
+		c.Accounts = utils.SliceIfNull(accounts)
+	}
+	return c, nil
+}
+
+// ConversationRead marks the conversation as read.
+func ConversationRead(ctx Context, id string) (models.Conversation, error) {
+	conversationsMu.Lock()
+	defer conversationsMu.Unlock()
+	c, err := conversationFind(ctx, id)
+	if err != nil {
+		return c, errors.WithStack(err)
+	}
+	state, err := conversationStateGet(ctx, id)
+	if err != nil {
+		return c, errors.WithStack(err)
+	}
+	state.LastReadID = c.LastStatus.ID
+	if err = conversationStateSet(ctx, id, state); err != nil {
+		return c, errors.WithStack(err)
+	}
+	c.Unread = false
+	return c, nil
+}
+
+// ConversationDelete removes the conversation until a new status is posted to it,
+// the statuses are not deleted.
+func ConversationDelete(ctx Context, id string) error {
+	conversationsMu.Lock()
+	defer conversationsMu.Unlock()
+	c, err := conversationFind(ctx, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	state, err := conversationStateGet(ctx, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	state.DeletedID = c.LastStatus.ID
+	return conversationStateSet(ctx, id, state)
+}
@@ -0,0 +1,118 @@
+package misskey_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDirectNotes answers the direct notes received by fakeUserID, from maps the note IDs to their authors.
+func fakeDirectNotes(from map[string]string) map[string]fakeHandler {
+	var ids []string
+	for id := range from {
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	note := func(id string) map[string]any {
+		return map[string]any{
+			"id":             id,
+			"createdAt":      time.Now().Format(time.RFC3339),
+			"userId":         from[id],
+			"user":           map[string]any{"id": from[id], "username": from[id]},
+			"visibility":     "specified",
+			"visibleUserIds": []string{fakeUserID},
+		}
+	}
+	return map[string]fakeHandler{
+		"/api/notes/mentions": func(body map[string]any) (int, any) {
+			untilID, _ := body["untilId"].(string)
+			limit := int(body["limit"].(float64))
+			notes := []map[string]any{}
+			for _, id := range ids {
+				if (untilID == "" || id < untilID) && len(notes) < limit {
+					notes = append(notes, note(id))
+				}
+			}
+			return http.StatusOK, notes
+		},
+		"/api/users/notes": func(map[string]any) (int, any) {
+			return http.StatusOK, []any{}
+		},
+		"/api/notes/show": func(body map[string]any) (int, any) {
+			id, _ := body["noteId"].(string)
+			if _, ok := from[id]; !ok {
+				return http.StatusBadRequest, map[string]any{"error": map[string]any{"code": "NO_SUCH_NOTE"}}
+			}
+			return http.StatusOK, note(id)
+		},
+		"/api/users/show": func(body map[string]any) (int, any) {
+			var users []map[string]any
+			for _, id := range body["userIds"].([]any) {
+				users = append(users, map[string]any{"id": id, "username": id})
+			}
+			return http.StatusOK, users
+		},
+		"/api/chat/history": func(map[string]any) (int, any) {
+			return http.StatusOK, []any{}
+		},
+	}
+}
+
+func TestConversationsPages(t *testing.T) {
+	from := map[string]string{"n9": "bob", "n8": "carol", "n7": "bob", "n6": "dave", "n5": "carol"}
+	f := newFakeMisskey(t, fakeDirectNotes(from))
+	ctx := f.Context()
+
+	first, err := misskey.Conversations(ctx, 2, "", "", "")
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, "n9", first[0].LastStatus.ID)
+	assert.Equal(t, "n8", first[1].LastStatus.ID)
+
+	// The conversations of the first page are not repeated with their older statuses.
+	second, err := misskey.Conversations(ctx, 2, first[1].LastStatus.ID, "", "")
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, "n6", second[0].LastStatus.ID)
+	require.Len(t, second[0].Accounts, 1)
+	assert.Equal(t, "dave", second[0].Accounts[0].ID)
+}
+
+func TestConversationsOlderThanAPage(t *testing.T) {
+	from := map[string]string{"a0000": "erin"}
+	for i := 1; i <= 250; i++ {
+		from[fmt.Sprintf("b%04d", i)] = "bob"
+	}
+	f := newFakeMisskey(t, fakeDirectNotes(from))
+	ctx := f.Context()
+
+	first, err := misskey.Conversations(ctx, 1, "", "", "")
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	assert.Equal(t, "b0250", first[0].LastStatus.ID)
+
+	// The notes are read across pages until a conversation is found.
+	second, err := misskey.Conversations(ctx, 1, first[0].LastStatus.ID, "", "")
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.Equal(t, "a0000", second[0].LastStatus.ID)
+	assert.Equal(t, 3, f.Calls("/api/notes/mentions")-1)
+
+	// A conversation outside the latest notes is found from its ID.
+	c, err := misskey.ConversationRead(ctx, second[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "a0000", c.LastStatus.ID)
+	assert.False(t, c.Unread)
+	require.Len(t, c.Accounts, 1)
+	assert.Equal(t, "erin", c.Accounts[0].ID)
+	require.NoError(t, misskey.ConversationDelete(ctx, second[0].ID))
+
+	_, err = misskey.ConversationRead(ctx, "unknown")
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+}
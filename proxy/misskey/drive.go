@@ -84,3 +84,17 @@ func driveFolderCreate(ctx Context, name string) (models.MkFolder, error) {
 	}
 	return folder, nil
 }
+
+func driveFileShow(ctx Context, id string) (file models.MkFile, err error) {
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"fileId": id})).
+		SetResult(&file).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/drive/files/show"))
+	if err != nil {
+		return file, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_FILE"); err != nil {
+		return file, errors.WithStack(err)
+	}
+	return file, nil
+}
@@ -0,0 +1,98 @@
+package misskey
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// localEmojisTTL is how long the emojis of a server are kept,
+	// localEmojisRetryDelay how long after a failed fetch they are fetched again.
+	localEmojisTTL        = time.Hour
+	localEmojisRetryDelay = time.Minute
+	localEmojisTimeout    = 10 * time.Second
+)
+
+// localEmojiList is the cached emojis of a server, by shortcode.
+type localEmojiList struct {
+	urls     map[string]string
+	expires  time.Time
+	fetching bool
+}
+
+var (
+	localEmojiListsMu sync.Mutex
+	localEmojiLists   = make(map[string]*localEmojiList)
+)
+
+// localEmojis returns the URLs of the custom emojis of the server by shortcode, Misskey does not send
+// them with the local notes and users. The emojis are fetched the first time, then refreshed
+// in the background once an hour, the requests get the old emojis meanwhile.
+func localEmojis(server string) map[string]string {
+	localEmojiListsMu.Lock()
+	l, ok := localEmojiLists[server]
+	if !ok {
+		l = &localEmojiList{}
+		localEmojiLists[server] = l
+	}
+	urls := l.urls
+	if l.fetching || time.Now().Before(l.expires) {
+		localEmojiListsMu.Unlock()
+		return urls
+	}
+	l.fetching = true
+	localEmojiListsMu.Unlock()
+
+	if urls == nil {
+		return localEmojisFetch(server, l)
+	}
+	go localEmojisFetch(server, l)
+	return urls
+}
+
+// localEmojisFetch fetches the emojis of the server into the list, the old emojis are kept
+// until the server answers again.
+func localEmojisFetch(server string, l *localEmojiList) map[string]string {
+	emojis, err := serverEmojis(server)
+	localEmojiListsMu.Lock()
+	defer localEmojiListsMu.Unlock()
+	l.fetching = false
+	if err != nil {
+		log.Debug().Err(err).Str("server", server).Msg("Failed to fetch the emojis of the server")
+		l.expires = time.Now().Add(localEmojisRetryDelay)
+		return l.urls
+	}
+	l.urls = make(map[string]string, len(emojis))
+	for _, e := range emojis {
+		l.urls[e.Name] = e.Url
+	}
+	l.expires = time.Now().Add(localEmojisTTL)
+	return l.urls
+}
+
+func serverEmojis(server string) ([]models.MkEmoji, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), localEmojisTimeout)
+	defer cancel()
+	var result struct {
+		Emojis []models.MkEmoji `json:"emojis"`
+	}
+	resp, err := client.R().
+		SetContext(ctx).
+		SetResult(&result).
+		SetBody(utils.Map{}).
+		Post(utils.JoinURL(server, "/api/emojis"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result.Emojis, nil
+}
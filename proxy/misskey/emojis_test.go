@@ -0,0 +1,59 @@
+package misskey_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalEmojis(t *testing.T) {
+	remoteHost := "remote.example"
+	f := newFakeMisskey(t, map[string]fakeHandler{
+		"/api/emojis": func(map[string]any) (int, any) {
+			return http.StatusOK, map[string]any{"emojis": []map[string]any{
+				{"name": "blobcat", "url": "https://local.example/blobcat.png"},
+			}}
+		},
+		"/api/notes/state": func(map[string]any) (int, any) {
+			return http.StatusOK, map[string]any{}
+		},
+		"/api/notes/show": func(body map[string]any) (int, any) {
+			if body["noteId"] == "remote" {
+				return http.StatusOK, map[string]any{
+					"id": "remote", "text": ":blobcat: :neko:", "userId": "u2",
+					"user":   map[string]any{"id": "u2", "username": "bob", "host": remoteHost},
+					"emojis": map[string]string{"neko": "https://remote.example/neko.png"},
+				}
+			}
+			return http.StatusOK, map[string]any{
+				"id": "local", "text": ":blobcat: :neko:", "userId": "u1",
+				"user":      map[string]any{"id": "u1", "username": "alice", "name": ":blobcat: Alice"},
+				"reactions": map[string]int{":blobcat@.:": 2},
+			}
+		},
+	})
+	defer f.Close()
+
+	status, err := misskey.StatusSingle(f.Context(), "local")
+	require.NoError(t, err)
+	require.Len(t, status.Emojis, 1)
+	assert.Equal(t, "blobcat", status.Emojis[0].Shortcode)
+	assert.Equal(t, "https://local.example/blobcat.png", status.Emojis[0].Url)
+	require.Len(t, status.Account.Emojis, 1)
+	assert.Equal(t, "blobcat", status.Account.Emojis[0].Shortcode)
+	require.Len(t, status.EmojiReactions, 1)
+	require.NotNil(t, status.EmojiReactions[0].Url)
+	assert.Equal(t, "https://local.example/blobcat.png", *status.EmojiReactions[0].Url)
+
+	// The emojis of the server are not the ones of remote notes.
+	status, err = misskey.StatusSingle(f.Context(), "remote")
+	require.NoError(t, err)
+	require.Len(t, status.Emojis, 1)
+	assert.Equal(t, "neko", status.Emojis[0].Shortcode)
+
+	// The emojis of the server are fetched once.
+	assert.Equal(t, 1, f.Calls("/api/emojis"))
+}
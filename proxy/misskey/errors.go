@@ -6,4 +6,6 @@ var (
 	ErrNotFound      = errors.New("not found")
 	ErrAcctIsInvalid = errors.New("acct format is invalid")
 	ErrRateLimit     = errors.New("rate limit")
+	ErrListReadOnly  = errors.New("list is read-only")
+	ErrNotSupported  = errors.New("not supported by the upstream server")
 )
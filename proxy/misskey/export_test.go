@@ -0,0 +1,14 @@
+package misskey
+
+import (
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+)
+
+// ScheduledStatusesPublishDue publishes the scheduled statuses due at now,
+// the index is created again as every test has its own storage.
+func ScheduledStatusesPublishDue(now time.Time) {
+	_ = storage.Default.CreateIndex(scheduledStatusesIndex, scheduledStatusesPrefix, "publish_at")
+	scheduledStatusesPublishDue(now)
+}
@@ -0,0 +1,91 @@
+package misskey_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+)
+
+const (
+	fakeUserID = "fake_user"
+	fakeToken  = "fake_token"
+)
+
+// fakeHandler answers a Misskey API call with its status code and JSON result.
+type fakeHandler func(body map[string]any) (int, any)
+
+// fakeMisskey is a Misskey server answering the API calls with the handlers by path,
+// "/api/i" answers that the token is the one of fakeUserID.
+type fakeMisskey struct {
+	*httptest.Server
+	mu       sync.Mutex
+	handlers map[string]fakeHandler
+	calls    map[string]int
+}
+
+func newFakeMisskey(t *testing.T, handlers map[string]fakeHandler) *fakeMisskey {
+	t.Helper()
+	// Every test has its own storage.
+	storage.Default = storage.NewMemory()
+	f := &fakeMisskey{handlers: handlers, calls: make(map[string]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body == nil {
+			body = make(map[string]any)
+		}
+		for k, v := range r.URL.Query() {
+			body[k] = v[0]
+		}
+		f.mu.Lock()
+		f.calls[r.URL.Path]++
+		handler, ok := f.handlers[r.URL.Path]
+		f.mu.Unlock()
+		code, result := http.StatusNotFound, any(map[string]any{"error": map[string]any{"code": "NO_SUCH_ENDPOINT"}})
+		switch {
+		case ok:
+			code, result = handler(body)
+		case r.URL.Path == "/api/i":
+			if body["i"] == fakeToken {
+				code, result = http.StatusOK, map[string]any{"id": fakeUserID}
+			} else {
+				code, result = http.StatusUnauthorized, map[string]any{"error": map[string]any{"code": "CREDENTIAL_REQUIRED"}}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if code != http.StatusNoContent {
+			_ = json.NewEncoder(w).Encode(result)
+		}
+	}))
+	t.Cleanup(f.Close)
+	utils.SetInsecureUpstreams([]string{f.Host()})
+	return f
+}
+
+// Host is the server of the fake Misskey, as the clients of the proxy give it.
+func (f *fakeMisskey) Host() string {
+	return strings.TrimPrefix(f.URL, "http://")
+}
+
+// Calls returns how many times the path has been called.
+func (f *fakeMisskey) Calls(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[path]
+}
+
+// Context returns the context of a request of fakeUserID to the fake server.
+func (f *fakeMisskey) Context() misskey.Context {
+	ctx := misstodon.ContextWithValues(f.Host(), fakeToken)
+	ctx.SetUserID(fakeUserID)
+	return ctx
+}
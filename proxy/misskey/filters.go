@@ -0,0 +1,413 @@
+package misskey
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
+)
+
+// Mastodon filters have a title, contexts, an expiry and an action that Misskey word mutes lack,
+// so they are kept in the proxy storage. Misskey word mutes apply to every timeline regardless
+// of the filter context, so only the keywords of the hide filters of the home timeline are synced
+// to the hardMutedWords of the user, for the Misskey clients. The other filters are only applied by the proxy.
+
+// filtersMu serializes filter updates and the word mute syncs.
+var filtersMu sync.Mutex
+
+// filtersSynced is the word mutes the proxy added to Misskey, as JSON-encoded entries.
+type filtersSynced struct {
+	HardMutedWords []string `json:"hardMutedWords"`
+}
+
+// Filters returns the filters of the user, expired ones included.
+func Filters(ctx Context) ([]models.Filter, error) {
+	prefix, err := storageKey(ctx, "filters", "")
+	if err != nil {
+		return nil, err
+	}
+	var filters []models.Filter
+	err = storage.Default.Ascend(prefix, func(_, value string) bool {
+		var f models.Filter
+		if err = json.Unmarshal([]byte(value), &f); err != nil {
+			return false
+		}
+		filters = append(filters, f)
+		return true
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return filters, nil
+}
+
+func FilterGet(ctx Context, id string) (models.Filter, error) {
+	var f models.Filter
+	key, err := storageKey(ctx, "filters", id)
+	if err != nil {
+		return f, err
+	}
+	value, err := storage.Default.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return f, ErrNotFound
+		}
+		return f, errors.WithStack(err)
+	}
+	if err = json.Unmarshal([]byte(value), &f); err != nil {
+		return f, errors.WithStack(err)
+	}
+	return f, nil
+}
+
+// FilterKeywordGet returns the keyword and the filter it belongs to.
+func FilterKeywordGet(ctx Context, id string) (models.Filter, models.FilterKeyword, error) {
+	filters, err := Filters(ctx)
+	if err != nil {
+		return models.Filter{}, models.FilterKeyword{}, err
+	}
+	for _, f := range filters {
+		for _, k := range f.Keywords {
+			if k.ID == id {
+				return f, k, nil
+			}
+		}
+	}
+	return models.Filter{}, models.FilterKeyword{}, ErrNotFound
+}
+
+// FilterStatusGet returns the status filter and the filter it belongs to.
+func FilterStatusGet(ctx Context, id string) (models.Filter, models.FilterStatus, error) {
+	filters, err := Filters(ctx)
+	if err != nil {
+		return models.Filter{}, models.FilterStatus{}, err
+	}
+	for _, f := range filters {
+		for _, s := range f.Statuses {
+			if s.ID == id {
+				return f, s, nil
+			}
+		}
+	}
+	return models.Filter{}, models.FilterStatus{}, ErrNotFound
+}
+
+// FilterSave creates or replaces the filter, giving IDs to it and its keywords and statuses if they have none.
+func FilterSave(ctx Context, f *models.Filter) error {
+	if f.ID == "" {
+		f.ID = xid.New().String()
+	}
+	for i := range f.Keywords {
+		if f.Keywords[i].ID == "" {
+			f.Keywords[i].ID = xid.New().String()
+		}
+	}
+	for i := range f.Statuses {
+		if f.Statuses[i].ID == "" {
+			f.Statuses[i].ID = xid.New().String()
+		}
+	}
+	if f.Context == nil {
+		f.Context = []models.FilterContext{}
+	}
+	if f.Keywords == nil {
+		f.Keywords = []models.FilterKeyword{}
+	}
+	if f.Statuses == nil {
+		f.Statuses = []models.FilterStatus{}
+	}
+	if f.FilterAction == "" {
+		f.FilterAction = models.FilterActionWarn
+	}
+	key, err := storageKey(ctx, "filters", f.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	if err = storage.Default.Set(key, string(data), 0); err != nil {
+		return errors.WithStack(err)
+	}
+	return filtersSync(ctx)
+}
+
+func FilterDelete(ctx Context, id string) error {
+	if _, err := FilterGet(ctx, id); err != nil {
+		return err
+	}
+	key, err := storageKey(ctx, "filters", id)
+	if err != nil {
+		return err
+	}
+	filtersMu.Lock()
+	defer filtersMu.Unlock()
+	if err = storage.Default.Delete(key); err != nil {
+		return errors.WithStack(err)
+	}
+	return filtersSync(ctx)
+}
+
+// filterWordMute returns the Misskey word mute entry of the keyword.
+func filterWordMute(k models.FilterKeyword) any {
+	if k.WholeWord {
+		return `/\b` + regexp.QuoteMeta(k.Keyword) + `\b/i`
+	}
+	return []string{k.Keyword}
+}
+
+// filtersWordMutes returns the word mutes of the hide filters of the home timeline that are not expired.
+func filtersWordMutes(filters []models.Filter) (synced filtersSynced) {
+	now := time.Now()
+	for _, f := range filters {
+		if f.Expired(now) || f.FilterAction != models.FilterActionHide ||
+			!slices.Contains(f.Context, models.FilterContextHome) {
+			continue
+		}
+		for _, k := range f.Keywords {
+			data, err := json.Marshal(filterWordMute(k))
+			if err != nil {
+				continue
+			}
+			synced.HardMutedWords = append(synced.HardMutedWords, string(data))
+		}
+	}
+	return
+}
+
+// mergeWordMutes replaces the entries previously added by the proxy with the current ones,
+// keeping the word mutes set by the user on Misskey.
+func mergeWordMutes(current []json.RawMessage, previous, desired []string) []json.RawMessage {
+	result := []json.RawMessage{}
+	for _, entry := range current {
+		var v any
+		if json.Unmarshal(entry, &v) != nil {
+			continue
+		}
+		data, _ := json.Marshal(v)
+		if slices.Contains(previous, string(data)) || slices.Contains(desired, string(data)) {
+			continue
+		}
+		result = append(result, data)
+	}
+	for _, entry := range desired {
+		result = append(result, json.RawMessage(entry))
+	}
+	return result
+}
+
+// filtersSync updates the Misskey word mutes of the user to match the filters, filtersMu must be held.
+func filtersSync(ctx Context) error {
+	filters, err := Filters(ctx)
+	if err != nil {
+		return err
+	}
+	syncedKey, err := storageKey(ctx, "filters_synced")
+	if err != nil {
+		return err
+	}
+	var previous filtersSynced
+	if value, err := storage.Default.Get(syncedKey); err == nil {
+		_ = json.Unmarshal([]byte(value), &previous)
+	}
+	desired := filtersWordMutes(filters)
+	if slices.Equal(previous.HardMutedWords, desired.HardMutedWords) {
+		return nil
+	}
+
+	var current struct {
+		HardMutedWords []json.RawMessage `json:"hardMutedWords"`
+	}
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{})).
+		SetResult(&current).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/i"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return errors.WithStack(err)
+	}
+	resp, err = client.R().
+		SetBody(makeBody(ctx, utils.Map{
+			"hardMutedWords": mergeWordMutes(current.HardMutedWords, previous.HardMutedWords, desired.HardMutedWords),
+		})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/i/update"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return errors.WithStack(err)
+	}
+	data, err := json.Marshal(desired)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return storage.Default.Set(syncedKey, string(data), 0)
+}
+
+// syncExpiredFilters removes the keywords of the filters that expired since the last sync from the word mutes.
+func syncExpiredFilters(ctx Context, filters []models.Filter) {
+	syncedKey, err := storageKey(ctx, "filters_synced")
+	if err != nil {
+		return
+	}
+	value, err := storage.Default.Get(syncedKey)
+	if err != nil {
+		return
+	}
+	data, _ := json.Marshal(filtersWordMutes(filters))
+	if value == string(data) {
+		return
+	}
+	go func() {
+		// A sync in progress will catch up with the expired filters.
+		if !filtersMu.TryLock() {
+			return
+		}
+		defer filtersMu.Unlock()
+		if err := filtersSync(ctx); err != nil {
+			log.Debug().Err(err).Str("server", ctx.ProxyServer()).Msg("Failed to sync word mutes")
+		}
+	}()
+}
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// statusFilterText returns the text of the status the keywords are matched against.
+func statusFilterText(s *models.Status) string {
+	texts := []string{s.SpoilerText, html.UnescapeString(htmlTagRegexp.ReplaceAllString(s.Content, " "))}
+	if s.Poll != nil {
+		for _, o := range s.Poll.Options {
+			texts = append(texts, o.Title)
+		}
+	}
+	for _, m := range s.MediaAttachments {
+		if m.Description != nil {
+			texts = append(texts, *m.Description)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+type filterMatcher struct {
+	filter   models.Filter
+	keywords []*regexp.Regexp
+}
+
+// filterMatchers returns the matchers of the filters of the user that apply to the context.
+func filterMatchers(ctx Context, context models.FilterContext) []filterMatcher {
+	if token := ctx.Token(); token == nil || *token == "" {
+		return nil
+	}
+	filters, err := Filters(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("server", ctx.ProxyServer()).Msg("Failed to load filters")
+		return nil
+	}
+	syncExpiredFilters(ctx, filters)
+	now := time.Now()
+	var matchers []filterMatcher
+	for _, f := range filters {
+		if f.Expired(now) || !slices.Contains(f.Context, context) {
+			continue
+		}
+		m := filterMatcher{filter: f}
+		for _, k := range f.Keywords {
+			pattern := regexp.QuoteMeta(k.Keyword)
+			if k.WholeWord {
+				pattern = `\b` + pattern + `\b`
+			}
+			m.keywords = append(m.keywords, regexp.MustCompile(`(?i)`+pattern))
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers
+}
+
+// applyFilterMatchers sets the filter results of the status, hide reports whether a hide filter matched.
+func applyFilterMatchers(matchers []filterMatcher, s *models.Status) (hide bool) {
+	target := s
+	if s.ReBlog != nil {
+		target = s.ReBlog
+	}
+	text := statusFilterText(target)
+	var results []models.FilterResult
+	for _, m := range matchers {
+		var result models.FilterResult
+		for i, k := range m.keywords {
+			if k.MatchString(text) {
+				result.KeywordMatches = append(result.KeywordMatches, m.filter.Keywords[i].Keyword)
+			}
+		}
+		for _, fs := range m.filter.Statuses {
+			if fs.StatusID == target.ID {
+				result.StatusMatches = append(result.StatusMatches, fs.StatusID)
+			}
+		}
+		if result.KeywordMatches == nil && result.StatusMatches == nil {
+			continue
+		}
+		result.Filter = m.filter
+		results = append(results, result)
+		hide = hide || m.filter.FilterAction == models.FilterActionHide
+	}
+	s.Filtered = results
+	if s.ReBlog != nil {
+		s.ReBlog.Filtered = results
+	}
+	return
+}
+
+// FiltersApply sets the filter results of the statuses shown in the context, removing the hidden ones.
+func FiltersApply(ctx Context, context models.FilterContext, statuses []models.Status) []models.Status {
+	matchers := filterMatchers(ctx, context)
+	if len(matchers) == 0 {
+		return statuses
+	}
+	result := statuses[:0]
+	for _, s := range statuses {
+		if !applyFilterMatchers(matchers, &s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// FiltersApplyStatus sets the filter results of a single status, it is not hidden.
+func FiltersApplyStatus(ctx Context, context models.FilterContext, status *models.Status) {
+	if matchers := filterMatchers(ctx, context); len(matchers) > 0 {
+		applyFilterMatchers(matchers, status)
+	}
+}
+
+// FiltersApplyNotifications sets the filter results of the statuses of the notifications,
+// removing the notifications of hidden statuses.
+func FiltersApplyNotifications(ctx Context, notifications []models.Notification) []models.Notification {
+	matchers := filterMatchers(ctx, models.FilterContextNotifications)
+	if len(matchers) == 0 {
+		return notifications
+	}
+	result := notifications[:0]
+	for _, n := range notifications {
+		if n.Status != nil && applyFilterMatchers(matchers, n.Status) {
+			continue
+		}
+		result = append(result, n)
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package misskey_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWordMutes answers "/api/i" and "/api/i/update" with the word mutes of fakeUserID.
+type fakeWordMutes struct {
+	mu             sync.Mutex
+	mutedWords     []any
+	hardMutedWords []any
+}
+
+func (m *fakeWordMutes) handlers() map[string]fakeHandler {
+	return map[string]fakeHandler{
+		"/api/i": func(body map[string]any) (int, any) {
+			if body["i"] != fakeToken {
+				return http.StatusUnauthorized, map[string]any{"error": map[string]any{"code": "CREDENTIAL_REQUIRED"}}
+			}
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return http.StatusOK, map[string]any{"id": fakeUserID, "mutedWords": m.mutedWords, "hardMutedWords": m.hardMutedWords}
+		},
+		"/api/i/update": func(body map[string]any) (int, any) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			// Misskey only updates the word mutes that are sent.
+			if v, ok := body["mutedWords"]; ok {
+				m.mutedWords, _ = v.([]any)
+			}
+			if v, ok := body["hardMutedWords"]; ok {
+				m.hardMutedWords, _ = v.([]any)
+			}
+			return http.StatusOK, map[string]any{"id": fakeUserID}
+		},
+	}
+}
+
+func (m *fakeWordMutes) get() (mutedWords, hardMutedWords []any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mutedWords, m.hardMutedWords
+}
+
+func TestFilterCRUD(t *testing.T) {
+	mutes := &fakeWordMutes{}
+	f := newFakeMisskey(t, mutes.handlers())
+	ctx := f.Context()
+
+	filter := models.Filter{
+		Title:    "spoilers",
+		Context:  []models.FilterContext{models.FilterContextHome},
+		Keywords: []models.FilterKeyword{{Keyword: "ending"}},
+	}
+	require.NoError(t, misskey.FilterSave(ctx, &filter))
+	assert.NotEmpty(t, filter.ID)
+	assert.NotEmpty(t, filter.Keywords[0].ID)
+	assert.Equal(t, models.FilterActionWarn, filter.FilterAction)
+	assert.NotNil(t, filter.Statuses)
+
+	got, err := misskey.FilterGet(ctx, filter.ID)
+	require.NoError(t, err)
+	assert.Equal(t, filter.Title, got.Title)
+
+	got.Statuses = append(got.Statuses, models.FilterStatus{StatusID: "note1"})
+	require.NoError(t, misskey.FilterSave(ctx, &got))
+
+	filters, err := misskey.Filters(ctx)
+	require.NoError(t, err)
+	require.Len(t, filters, 1)
+	assert.Len(t, filters[0].Statuses, 1)
+
+	owner, keyword, err := misskey.FilterKeywordGet(ctx, filter.Keywords[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, filter.ID, owner.ID)
+	assert.Equal(t, "ending", keyword.Keyword)
+
+	owner, status, err := misskey.FilterStatusGet(ctx, got.Statuses[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, filter.ID, owner.ID)
+	assert.Equal(t, "note1", status.StatusID)
+
+	require.NoError(t, misskey.FilterDelete(ctx, filter.ID))
+	_, err = misskey.FilterGet(ctx, filter.ID)
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+	assert.ErrorIs(t, misskey.FilterDelete(ctx, filter.ID), misskey.ErrNotFound)
+	_, _, err = misskey.FilterKeywordGet(ctx, filter.Keywords[0].ID)
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+
+	filters, err = misskey.Filters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, filters)
+}
+
+func TestFilterUnauthorized(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	ctx := misstodon.ContextWithValues(f.Host(), "wrong_token")
+
+	_, err := misskey.Filters(ctx)
+	assert.ErrorIs(t, err, misskey.ErrUnauthorized)
+	assert.ErrorIs(t, misskey.FilterSave(ctx, &models.Filter{Title: "test"}), misskey.ErrUnauthorized)
+}
+
+func TestFilterWordMutesSync(t *testing.T) {
+	mutes := &fakeWordMutes{
+		mutedWords:     []any{[]any{"user"}},
+		hardMutedWords: []any{"/user/"},
+	}
+	f := newFakeMisskey(t, mutes.handlers())
+	ctx := f.Context()
+
+	hide := models.Filter{
+		Title:        "hide",
+		Context:      []models.FilterContext{models.FilterContextHome, models.FilterContextNotifications},
+		FilterAction: models.FilterActionHide,
+		Keywords:     []models.FilterKeyword{{Keyword: "hidden"}, {Keyword: "word", WholeWord: true}},
+	}
+	require.NoError(t, misskey.FilterSave(ctx, &hide))
+	warn := models.Filter{
+		Title:        "warn",
+		Context:      []models.FilterContext{models.FilterContextHome},
+		FilterAction: models.FilterActionWarn,
+		Keywords:     []models.FilterKeyword{{Keyword: "warned"}},
+	}
+	require.NoError(t, misskey.FilterSave(ctx, &warn))
+	notifications := models.Filter{
+		Title:        "notifications",
+		Context:      []models.FilterContext{models.FilterContextNotifications},
+		FilterAction: models.FilterActionHide,
+		Keywords:     []models.FilterKeyword{{Keyword: "notified"}},
+	}
+	require.NoError(t, misskey.FilterSave(ctx, &notifications))
+
+	// Only the hide filters of the home timeline are synced, the word mutes of the user are kept.
+	mutedWords, hardMutedWords := mutes.get()
+	assert.Equal(t, []any{[]any{"user"}}, mutedWords)
+	assert.Equal(t, []any{"/user/", []any{"hidden"}, `/\bword\b/i`}, hardMutedWords)
+
+	hide.Keywords = hide.Keywords[:1]
+	require.NoError(t, misskey.FilterSave(ctx, &hide))
+	_, hardMutedWords = mutes.get()
+	assert.Equal(t, []any{"/user/", []any{"hidden"}}, hardMutedWords)
+
+	require.NoError(t, misskey.FilterDelete(ctx, hide.ID))
+	mutedWords, hardMutedWords = mutes.get()
+	assert.Equal(t, []any{[]any{"user"}}, mutedWords)
+	assert.Equal(t, []any{"/user/"}, hardMutedWords)
+}
+
+func TestFiltersApply(t *testing.T) {
+	mutes := &fakeWordMutes{}
+	f := newFakeMisskey(t, mutes.handlers())
+	ctx := f.Context()
+
+	require.NoError(t, misskey.FilterSave(ctx, &models.Filter{
+		Title:        "warn",
+		Context:      []models.FilterContext{models.FilterContextHome},
+		FilterAction: models.FilterActionWarn,
+		Keywords:     []models.FilterKeyword{{Keyword: "cat", WholeWord: true}},
+	}))
+	require.NoError(t, misskey.FilterSave(ctx, &models.Filter{
+		Title:        "hide",
+		Context:      []models.FilterContext{models.FilterContextHome},
+		FilterAction: models.FilterActionHide,
+		Keywords:     []models.FilterKeyword{{Keyword: "dog"}},
+	}))
+	require.NoError(t, misskey.FilterSave(ctx, &models.Filter{
+		Title:        "thread",
+		Context:      []models.FilterContext{models.FilterContextThread},
+		FilterAction: models.FilterActionHide,
+		Keywords:     []models.FilterKeyword{{Keyword: "bird"}},
+	}))
+
+	statuses := misskey.FiltersApply(ctx, models.FilterContextHome, []models.Status{
+		{ID: "1", Content: "<p>A <b>Cat</b> sleeps</p>"},
+		{ID: "2", Content: "<p>Concatenate</p>"},
+		{ID: "3", Content: "<p>hotdogs</p>"},
+		{ID: "4", Content: "<p>A bird</p>"},
+	})
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "1", statuses[0].ID)
+	require.Len(t, statuses[0].Filtered, 1)
+	assert.Equal(t, "warn", statuses[0].Filtered[0].Filter.Title)
+	assert.Equal(t, []string{"cat"}, statuses[0].Filtered[0].KeywordMatches)
+	assert.Equal(t, "2", statuses[1].ID)
+	assert.Empty(t, statuses[1].Filtered)
+	assert.Equal(t, "4", statuses[2].ID)
+	assert.Empty(t, statuses[2].Filtered)
+
+	statuses = misskey.FiltersApply(ctx, models.FilterContextThread, []models.Status{{ID: "4", Content: "<p>A bird</p>"}})
+	assert.Empty(t, statuses)
+}
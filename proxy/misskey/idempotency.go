@@ -0,0 +1,113 @@
+package misskey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// IdempotencyTTL is how long the status created for an idempotency key is remembered, as in Mastodon.
+const IdempotencyTTL = time.Hour
+
+const (
+	idempotencyStatusPrefix          = "status:"
+	idempotencyScheduledStatusPrefix = "scheduled_status:"
+)
+
+// idempotencyCall is a status creation in flight, the repeats of its key wait for it.
+type idempotencyCall struct {
+	done   chan struct{}
+	status any
+	err    error
+}
+
+var (
+	idempotencyMu    sync.Mutex
+	idempotencyCalls = make(map[string]*idempotencyCall)
+)
+
+// StatusCreateIdempotent calls create once for the idempotency key of the user,
+// the repeats of the key return the status that was created by the first call.
+// create returns a models.Status, or a models.ScheduledStatus.
+func StatusCreateIdempotent(ctx Context, idempotencyKey string, create func() (any, error)) (any, error) {
+	if idempotencyKey == "" {
+		return create()
+	}
+	sum := sha256.Sum256([]byte(idempotencyKey))
+	key, err := storageKey(ctx, "idempotency", hex.EncodeToString(sum[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyMu.Lock()
+	if call, ok := idempotencyCalls[key]; ok {
+		idempotencyMu.Unlock()
+		<-call.done
+		return call.status, call.err
+	}
+	call := &idempotencyCall{done: make(chan struct{})}
+	idempotencyCalls[key] = call
+	idempotencyMu.Unlock()
+	defer func() {
+		idempotencyMu.Lock()
+		delete(idempotencyCalls, key)
+		idempotencyMu.Unlock()
+		close(call.done)
+	}()
+
+	if call.status, call.err = idempotencyDuplicate(ctx, key); call.status != nil || call.err != nil {
+		return call.status, call.err
+	}
+	call.status, call.err = create()
+	if call.err != nil {
+		return call.status, call.err
+	}
+	var ref string
+	switch s := call.status.(type) {
+	case models.Status:
+		ref = idempotencyStatusPrefix + s.ID
+	case models.ScheduledStatus:
+		ref = idempotencyScheduledStatusPrefix + s.ID
+	}
+	if ref != "" {
+		// The status has been created, a failure to remember it only lets a repeat create it again.
+		if err = storage.Default.Set(key, ref, IdempotencyTTL); err != nil {
+			log.Warn().Err(err).Str("server", ctx.ProxyServer()).Msg("Failed to save the idempotency key")
+		}
+	}
+	return call.status, nil
+}
+
+// idempotencyDuplicate returns the status that was created for the key, or nil.
+// A status that has been deleted since is not returned, so it can be created again.
+func idempotencyDuplicate(ctx Context, key string) (any, error) {
+	ref, err := storage.Default.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	var status any
+	if id, ok := strings.CutPrefix(ref, idempotencyScheduledStatusPrefix); ok {
+		status, err = ScheduledStatusGet(ctx, id)
+	} else if id, ok := strings.CutPrefix(ref, idempotencyStatusPrefix); ok {
+		status, err = StatusSingle(ctx, id)
+	} else {
+		return nil, nil
+	}
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return status, nil
+}
@@ -0,0 +1,143 @@
+package misskey_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotes answers notes/show and notes/state with the notes of the set, the others are missing.
+func fakeNotes(mu *sync.Mutex, notes map[string]bool) map[string]fakeHandler {
+	return map[string]fakeHandler{
+		"/api/notes/show": func(body map[string]any) (int, any) {
+			mu.Lock()
+			defer mu.Unlock()
+			id, _ := body["noteId"].(string)
+			if !notes[id] {
+				return http.StatusBadRequest, map[string]any{"error": map[string]any{"code": "NO_SUCH_NOTE"}}
+			}
+			return http.StatusOK, map[string]any{
+				"id":        id,
+				"createdAt": time.Now().Format(time.RFC3339),
+				"userId":    fakeUserID,
+				"user":      map[string]any{"id": fakeUserID, "username": "fake"},
+			}
+		},
+		"/api/notes/state": func(body map[string]any) (int, any) {
+			return http.StatusOK, map[string]any{"isFavorited": false, "isMutedThread": false}
+		},
+	}
+}
+
+// countingCreate returns a create function posting a new note of the set for every call.
+func countingCreate(mu *sync.Mutex, notes map[string]bool, calls *atomic.Int32, wait <-chan struct{}) func() (any, error) {
+	return func() (any, error) {
+		if wait != nil {
+			<-wait
+		}
+		id := "note" + string(rune('0'+calls.Add(1)))
+		mu.Lock()
+		notes[id] = true
+		mu.Unlock()
+		return models.Status{ID: id}, nil
+	}
+}
+
+func TestStatusCreateIdempotent(t *testing.T) {
+	var mu sync.Mutex
+	notes := make(map[string]bool)
+	f := newFakeMisskey(t, fakeNotes(&mu, notes))
+	ctx := f.Context()
+	var calls atomic.Int32
+	create := countingCreate(&mu, notes, &calls, nil)
+
+	first, err := misskey.StatusCreateIdempotent(ctx, "key", create)
+	require.NoError(t, err)
+	repeat, err := misskey.StatusCreateIdempotent(ctx, "key", create)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, first.(models.Status).ID, repeat.(models.Status).ID)
+
+	other, err := misskey.StatusCreateIdempotent(ctx, "other", create)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.NotEqual(t, first.(models.Status).ID, other.(models.Status).ID)
+
+	// A deleted status is created again.
+	mu.Lock()
+	delete(notes, first.(models.Status).ID)
+	mu.Unlock()
+	again, err := misskey.StatusCreateIdempotent(ctx, "key", create)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.NotEqual(t, first.(models.Status).ID, again.(models.Status).ID)
+}
+
+func TestStatusCreateIdempotentConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	notes := make(map[string]bool)
+	f := newFakeMisskey(t, fakeNotes(&mu, notes))
+	ctx := f.Context()
+	var calls atomic.Int32
+	wait := make(chan struct{})
+	create := countingCreate(&mu, notes, &calls, wait)
+
+	const callers = 5
+	results := make([]any, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			status, err := misskey.StatusCreateIdempotent(ctx, "key", create)
+			assert.NoError(t, err)
+			results[i] = status
+		}(i)
+	}
+	// The repeats wait for the creation in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(wait)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	for _, status := range results {
+		assert.Equal(t, "note1", status.(models.Status).ID)
+	}
+}
+
+// failingIdempotencyStorage fails to save the idempotency keys.
+type failingIdempotencyStorage struct {
+	storage.Storage
+}
+
+func (s failingIdempotencyStorage) Set(key, value string, ttl time.Duration) error {
+	if strings.Contains(key, "idempotency") {
+		return errors.New("storage is full")
+	}
+	return s.Storage.Set(key, value, ttl)
+}
+
+func TestStatusCreateIdempotentStorageError(t *testing.T) {
+	var mu sync.Mutex
+	notes := make(map[string]bool)
+	f := newFakeMisskey(t, fakeNotes(&mu, notes))
+	memory := storage.Default
+	storage.Default = failingIdempotencyStorage{memory}
+	t.Cleanup(func() { storage.Default = memory })
+	ctx := f.Context()
+	var calls atomic.Int32
+
+	// The status has been created, so it is returned.
+	status, err := misskey.StatusCreateIdempotent(ctx, "key", countingCreate(&mu, notes, &calls, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "note1", status.(models.Status).ID)
+}
@@ -138,20 +138,11 @@ func InstancePeers(server string) ([]string, error) {
 }
 
 func InstanceCustomEmojis(server string) ([]models.CustomEmoji, error) {
-	var emojis struct {
-		Emojis []models.MkEmoji `json:"emojis"`
-	}
-	resp, err := client.R().
-		SetResult(&emojis).
-		SetBody(utils.Map{}).
-		Post(utils.JoinURL(server, "/api/emojis"))
+	emojis, err := serverEmojis(server)
 	if err != nil {
 		return nil, err
 	}
-	if err = isucceed(resp, http.StatusOK); err != nil {
-		return nil, errors.WithStack(err)
-	}
-	return lo.Map(emojis.Emojis, func(e models.MkEmoji, _ int) models.CustomEmoji {
+	return lo.Map(emojis, func(e models.MkEmoji, _ int) models.CustomEmoji {
 		return e.ToCustomEmoji()
 	}), nil
 }
@@ -0,0 +1,331 @@
+package misskey
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// listMembersPageLimit is the most members of a list Misskey returns at once.
+const listMembersPageLimit = 100
+
+// errNoMemberships reports a Misskey without users/lists/get-memberships, its lists have the IDs of their members.
+var errNoMemberships = errors.New("list memberships are not supported")
+
+func userListShow(ctx Context, id string) (models.MkUserList, error) {
+	var result models.MkUserList
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"listId": id})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/show"))
+	if err != nil {
+		return result, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+		return result, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func userLists(ctx Context) ([]models.MkUserList, error) {
+	var result []models.MkUserList
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/list"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+// readOnlyListGet returns the list backed by an antenna or a channel, ok is false for user lists.
+func readOnlyListGet(ctx Context, id string) (list models.List, ok bool, err error) {
+	if antennaID, ok := models.AntennaID(id); ok {
+		antenna, err := antennaShow(ctx, antennaID)
+		return antenna.ToList(), true, err
+	}
+	if channelID, ok := models.ChannelID(id); ok {
+		channel, err := channelShow(ctx, channelID)
+		return channel.ToList(), true, err
+	}
+	return list, false, nil
+}
+
+func isReadOnlyList(id string) bool {
+	_, antenna := models.AntennaID(id)
+	_, channel := models.ChannelID(id)
+	return antenna || channel
+}
+
+// Lists returns the user lists followed by the antennas and the followed channels of the user.
+func Lists(ctx Context) ([]models.List, error) {
+	lists, err := userLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := lo.Map(lists, func(l models.MkUserList, _ int) models.List { return l.ToList() })
+	// Tokens issued before the antennas and channels were exposed as lists may not be
+	// allowed to read them, the lists of the user are still returned.
+	antennaList, err := antennas(ctx)
+	if err != nil && !errors.Is(err, ErrUnauthorized) {
+		return nil, err
+	}
+	for _, a := range antennaList {
+		result = append(result, a.ToList())
+	}
+	channels, err := channelsFollowed(ctx)
+	if err != nil && !errors.Is(err, ErrUnauthorized) {
+		return nil, err
+	}
+	for _, c := range channels {
+		result = append(result, c.ToList())
+	}
+	return result, nil
+}
+
+func ListGet(ctx Context, id string) (models.List, error) {
+	if list, ok, err := readOnlyListGet(ctx, id); ok {
+		return list, err
+	}
+	list, err := userListShow(ctx, id)
+	if err != nil {
+		return models.List{}, err
+	}
+	return list.ToList(), nil
+}
+
+func ListCreate(ctx Context, title string) (models.List, error) {
+	var result models.MkUserList
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"name": title})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/create"))
+	if err != nil {
+		return models.List{}, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return models.List{}, errors.WithStack(err)
+	}
+	return result.ToList(), nil
+}
+
+func ListUpdate(ctx Context, id, title string) (models.List, error) {
+	if isReadOnlyList(id) {
+		return models.List{}, ErrListReadOnly
+	}
+	var result models.MkUserList
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"listId": id, "name": title})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/update"))
+	if err != nil {
+		return models.List{}, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+		return models.List{}, errors.WithStack(err)
+	}
+	return result.ToList(), nil
+}
+
+func ListDelete(ctx Context, id string) error {
+	if isReadOnlyList(id) {
+		return ErrListReadOnly
+	}
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"listId": id})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/delete"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// listMemberships returns the memberships of the list older than maxID and newer than sinceID,
+// at most limit of them, all of them if limit is not positive.
+func listMemberships(ctx Context, id string, limit int, maxID, sinceID string) ([]models.MkUserListMembership, error) {
+	var result []models.MkUserListMembership
+	// Misskey returns the oldest memberships first when only sinceId is set.
+	ascending := maxID == "" && sinceID != ""
+	for limit <= 0 || len(result) < limit {
+		pageLimit := listMembersPageLimit
+		if limit > 0 {
+			pageLimit = min(pageLimit, limit-len(result))
+		}
+		body := makeBody(ctx, utils.Map{"listId": id, "limit": pageLimit})
+		if maxID != "" {
+			body["untilId"] = maxID
+		}
+		if sinceID != "" {
+			body["sinceId"] = sinceID
+		}
+		var page []models.MkUserListMembership
+		resp, err := client.R().
+			SetBody(body).
+			SetResult(&page).
+			Post(utils.JoinURL(ctx.ProxyServer(), "/api/users/lists/get-memberships"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// Misskey reports a missing list with an error code, so a missing endpoint is what is not found.
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, errNoMemberships
+		}
+		if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		result = append(result, page...)
+		if len(page) < pageLimit {
+			break
+		}
+		if ascending {
+			sinceID = page[len(page)-1].ID
+		} else {
+			maxID = page[len(page)-1].ID
+		}
+	}
+	return result, nil
+}
+
+// ListAccounts returns the accounts of the list older than maxID and newer than sinceID,
+// at most limit of them if limit is positive. Antennas and channels match notes rather than accounts,
+// so they have no accounts.
+func ListAccounts(ctx Context, id string, limit int, maxID, sinceID string) ([]models.Account, error) {
+	if _, ok, err := readOnlyListGet(ctx, id); ok {
+		return nil, err
+	}
+	memberships, err := listMemberships(ctx, id, limit, maxID, sinceID)
+	if errors.Is(err, errNoMemberships) {
+		return listAccountsByIDs(ctx, id, limit, maxID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var accounts []models.Account
+	for _, m := range memberships {
+		if m.User == nil {
+			continue
+		}
+		if a, err := userToAccount(ctx, m.User); err == nil {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts, nil
+}
+
+// listAccountsByIDs returns the accounts of the list from the IDs of its members, for the Misskey versions
+// without memberships. maxID is the last account of the previous page.
+func listAccountsByIDs(ctx Context, id string, limit int, maxID string) ([]models.Account, error) {
+	list, err := userListShow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := list.UserIds
+	if i := slices.Index(userIDs, maxID); maxID != "" && i >= 0 {
+		userIDs = userIDs[i+1:]
+	}
+	if limit > 0 && len(userIDs) > limit {
+		userIDs = userIDs[:limit]
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	return usersShow(ctx, userIDs)
+}
+
+func listMembership(ctx Context, endpoint, id string, userIDs []string, allowedCodes ...string) error {
+	if isReadOnlyList(id) {
+		return ErrListReadOnly
+	}
+	for _, userID := range userIDs {
+		resp, err := client.R().
+			SetBody(makeBody(ctx, utils.Map{"listId": id, "userId": userID})).
+			Post(utils.JoinURL(ctx.ProxyServer(), endpoint))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		// The allowed codes report a membership that is already as requested.
+		if isucceed(resp, http.StatusNoContent, allowedCodes...) == nil {
+			continue
+		}
+		if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func ListAccountsAdd(ctx Context, id string, userIDs []string) error {
+	return listMembership(ctx, "/api/users/lists/push", id, userIDs, "ALREADY_ADDED")
+}
+
+func ListAccountsRemove(ctx Context, id string, userIDs []string) error {
+	return listMembership(ctx, "/api/users/lists/pull", id, userIDs)
+}
+
+// AccountLists returns the lists of the user that contain the account.
+func AccountLists(ctx Context, userID string) ([]models.List, error) {
+	lists, err := userLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.List
+	memberships := true
+	for _, l := range lists {
+		member := utils.Contains(l.UserIds, userID)
+		if memberships {
+			list, err := listMemberships(ctx, l.ID, 0, "", "")
+			switch {
+			case errors.Is(err, errNoMemberships):
+				memberships = false
+			case err != nil:
+				return nil, err
+			default:
+				member = slices.ContainsFunc(list, func(m models.MkUserListMembership) bool { return m.UserId == userID })
+			}
+		}
+		if member {
+			result = append(result, l.ToList())
+		}
+	}
+	return result, nil
+}
+
+func TimelineList(ctx Context, id string,
+	limit int, maxId, minId string) ([]models.Status, error) {
+	if antennaID, ok := models.AntennaID(id); ok {
+		return antennaNotes(ctx, antennaID, limit, maxId, minId)
+	}
+	if channelID, ok := models.ChannelID(id); ok {
+		return channelTimeline(ctx, channelID, limit, maxId, minId)
+	}
+	body := makeBody(ctx, utils.Map{"listId": id, "limit": limit})
+	if minId != "" {
+		body["sinceId"] = minId
+	}
+	if maxId != "" {
+		body["untilId"] = maxId
+	}
+	var result []models.MkNote
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/user-list-timeline"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_LIST"); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return notesToStatuses(ctx, result), nil
+}
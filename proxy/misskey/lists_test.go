@@ -0,0 +1,104 @@
+package misskey_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMemberships answers users/lists/get-memberships with members m001 to m250 of the list "list",
+// their memberships are m001 to m250 as well, newest first. The list "other" is empty.
+func fakeMemberships() fakeHandler {
+	return func(body map[string]any) (int, any) {
+		switch body["listId"] {
+		case "other":
+			return http.StatusOK, []any{}
+		case "list":
+		default:
+			return http.StatusBadRequest, map[string]any{"error": map[string]any{"code": "NO_SUCH_LIST"}}
+		}
+		limit := int(body["limit"].(float64))
+		untilID, _ := body["untilId"].(string)
+		var result []map[string]any
+		for i := 250; i > 0 && len(result) < limit; i-- {
+			id := fmt.Sprintf("m%03d", i)
+			if untilID != "" && id >= untilID {
+				continue
+			}
+			result = append(result, map[string]any{
+				"id": id, "userId": id, "user": map[string]any{"id": id, "username": id},
+			})
+		}
+		return http.StatusOK, result
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	f := newFakeMisskey(t, map[string]fakeHandler{
+		"/api/users/lists/get-memberships": fakeMemberships(),
+		"/api/users/lists/list": func(map[string]any) (int, any) {
+			return http.StatusOK, []map[string]any{{"id": "list", "name": "list"}, {"id": "other", "name": "other"}}
+		},
+	})
+	defer f.Close()
+	ctx := f.Context()
+
+	accounts, err := misskey.ListAccounts(ctx, "list", 40, "", "")
+	require.NoError(t, err)
+	require.Len(t, accounts, 40)
+	assert.Equal(t, "m250", accounts[0].ID)
+
+	accounts, err = misskey.ListAccounts(ctx, "list", 40, accounts[39].ID, "")
+	require.NoError(t, err)
+	require.Len(t, accounts, 40)
+	assert.Equal(t, "m210", accounts[0].ID)
+
+	// limit=0 returns all the accounts, page by page.
+	calls := f.Calls("/api/users/lists/get-memberships")
+	accounts, err = misskey.ListAccounts(ctx, "list", 0, "", "")
+	require.NoError(t, err)
+	assert.Len(t, accounts, 250)
+	assert.Equal(t, 3, f.Calls("/api/users/lists/get-memberships")-calls)
+
+	_, err = misskey.ListAccounts(ctx, "missing", 40, "", "")
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+
+	lists, err := misskey.AccountLists(ctx, "m001")
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, "list", lists[0].ID)
+}
+
+func TestListAccountsWithoutMemberships(t *testing.T) {
+	f := newFakeMisskey(t, map[string]fakeHandler{
+		"/api/users/lists/show": func(map[string]any) (int, any) {
+			return http.StatusOK, map[string]any{"id": "list", "userIds": []string{"u1", "u2", "u3"}}
+		},
+		"/api/users/lists/list": func(map[string]any) (int, any) {
+			return http.StatusOK, []map[string]any{{"id": "list", "userIds": []string{"u1", "u2", "u3"}}}
+		},
+		"/api/users/show": func(body map[string]any) (int, any) {
+			var result []map[string]any
+			for _, id := range body["userIds"].([]any) {
+				result = append(result, map[string]any{"id": id, "username": id})
+			}
+			return http.StatusOK, result
+		},
+	})
+	defer f.Close()
+	ctx := f.Context()
+
+	accounts, err := misskey.ListAccounts(ctx, "list", 2, "u1", "")
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, "u2", accounts[0].ID)
+	assert.Equal(t, "u3", accounts[1].ID)
+
+	lists, err := misskey.AccountLists(ctx, "u2")
+	require.NoError(t, err)
+	assert.Len(t, lists, 1)
+}
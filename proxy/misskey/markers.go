@@ -0,0 +1,117 @@
+package misskey
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MarkersBackendStorage keeps the markers in the proxy storage.
+	MarkersBackendStorage = "storage"
+	// MarkersBackendRegistry keeps the markers in the Misskey registry of the user,
+	// so they follow the user across misstodon deployments.
+	MarkersBackendRegistry = "registry"
+)
+
+// MarkersBackend is where the read markers are kept, Misskey has no read markers of its own.
+var MarkersBackend = MarkersBackendStorage
+
+// MarkerTimelines are the timelines a read marker can be saved for.
+var MarkerTimelines = []string{"home", "notifications"}
+
+// markersMu serializes marker updates so versions are not lost.
+var markersMu sync.Mutex
+
+func markersRegistryScope() []string {
+	return append(append([]string{}, RegistryScope...), "markers")
+}
+
+func markerKey(ctx Context, timeline string) (string, error) {
+	return storageKey(ctx, "markers", timeline)
+}
+
+// markerGet returns the marker of the timeline, or ErrNotFound.
+func markerGet(ctx Context, timeline string) (marker models.Marker, err error) {
+	if MarkersBackend == MarkersBackendRegistry {
+		err = RegistryGet(ctx, markersRegistryScope(), timeline, &marker)
+		return
+	}
+	key, err := markerKey(ctx, timeline)
+	if err != nil {
+		return
+	}
+	value, err := storage.Default.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = ErrNotFound
+		}
+		return
+	}
+	err = json.Unmarshal([]byte(value), &marker)
+	return
+}
+
+func markerSet(ctx Context, timeline string, marker models.Marker) error {
+	if MarkersBackend == MarkersBackendRegistry {
+		return RegistrySet(ctx, markersRegistryScope(), timeline, marker)
+	}
+	key, err := markerKey(ctx, timeline)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	return storage.Default.Set(key, string(data), 0)
+}
+
+func MarkersGet(ctx Context, timelines []string) (map[string]models.Marker, error) {
+	markers := make(map[string]models.Marker)
+	for _, timeline := range timelines {
+		if !utils.Contains(MarkerTimelines, timeline) {
+			continue
+		}
+		marker, err := markerGet(ctx, timeline)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, errors.WithStack(err)
+		}
+		markers[timeline] = marker
+	}
+	return markers, nil
+}
+
+// MarkersSet saves the last read IDs by timeline and returns the updated markers.
+func MarkersSet(ctx Context, lastReadIDs map[string]string) (map[string]models.Marker, error) {
+	markersMu.Lock()
+	defer markersMu.Unlock()
+	markers := make(map[string]models.Marker)
+	for timeline, lastReadID := range lastReadIDs {
+		if !utils.Contains(MarkerTimelines, timeline) || lastReadID == "" {
+			continue
+		}
+		current, err := markerGet(ctx, timeline)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, errors.WithStack(err)
+		}
+		marker := models.Marker{
+			LastReadID: lastReadID,
+			Version:    current.Version + 1,
+			UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+		}
+		if err = markerSet(ctx, timeline, marker); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		markers[timeline] = marker
+	}
+	return markers, nil
+}
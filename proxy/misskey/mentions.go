@@ -0,0 +1,66 @@
+package misskey
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/rs/zerolog/log"
+)
+
+// mentionsCacheTTL is how long a mentioned user is remembered, so the users
+// mentioned again and again in the timelines are not looked up for every page.
+const mentionsCacheTTL = 6 * time.Hour
+
+func mentionKey(server, userID string) string {
+	return storage.Key(server, "mentions", userID)
+}
+
+// statusMentions returns the mentions of the users, the users that are not cached
+// are looked up at once. The users that cannot be found are left out.
+func statusMentions(ctx Context, userIDs []string) map[string]models.StatusMention {
+	mentions := make(map[string]models.StatusMention)
+	var missing []string
+	for _, id := range userIDs {
+		if _, ok := mentions[id]; ok {
+			continue
+		}
+		var m models.StatusMention
+		value, err := storage.Default.Get(mentionKey(ctx.ProxyServer(), id))
+		if err == nil && json.Unmarshal([]byte(value), &m) == nil {
+			mentions[id] = m
+			continue
+		}
+		if !utils.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return mentions
+	}
+	accounts, err := usersShow(ctx, missing)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to resolve the mentioned users")
+		return mentions
+	}
+	for _, a := range accounts {
+		m := a.ToStatusMention()
+		mentions[a.ID] = m
+		if data, err := json.Marshal(m); err == nil {
+			_ = storage.Default.Set(mentionKey(ctx.ProxyServer(), a.ID), string(data), mentionsCacheTTL)
+		}
+	}
+	return mentions
+}
+
+// setStatusMentions fills the mentions of the status converted from the note.
+func setStatusMentions(s *models.Status, n *models.MkNote, mentions map[string]models.StatusMention) {
+	s.Mentions = []models.StatusMention{}
+	for _, id := range n.Mentions {
+		if m, ok := mentions[id]; ok {
+			s.Mentions = append(s.Mentions, m)
+		}
+	}
+}
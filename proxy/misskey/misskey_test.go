@@ -21,9 +21,10 @@ func TestMain(m *testing.M) {
 		Out:        os.Stderr,
 		TimeFormat: "2006-01-02 15:04:05",
 	})
+	// Without the .env file the tests against a real server are skipped,
+	// the tests against a fake server still run.
 	if err := godotenv.Load(); err != nil {
-		log.Error().Err(err).Msg("failed to load .env file")
-		return
+		log.Warn().Err(err).Msg("failed to load .env file")
 	}
 	testServer = os.Getenv("TEST_SERVER")
 	testToken = os.Getenv("TEST_TOKEN")
@@ -34,7 +34,7 @@ func MutesList(ctx Context, limit int, sinceID, maxID string) ([]models.Account,
 	}
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.Mutee.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.Mutee); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -53,13 +53,20 @@ func NotificationsGet(ctx Context,
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return nil, errors.WithStack(err)
 	}
+	var statuses []*models.Status
+	var notes []*models.MkNote
 	notifications := lo.Map(result, func(item models.MkNotification, _ int) models.Notification {
-		n, err := item.ToNotification(ctx.ProxyServer())
+		n, err := toNotification(ctx, &item)
 		if err == nil {
 			return n
 		}
 		return models.Notification{Type: models.NotificationTypeUnknown}
 	})
+	for i := range notifications {
+		statuses = append(statuses, notifications[i].Status)
+		notes = append(notes, result[i].Note)
+	}
+	completeStatuses(ctx, statuses, notes)
 	notifications = lo.Filter(notifications, func(item models.Notification, _ int) bool {
 		return item.Type != models.NotificationTypeUnknown
 	})
@@ -109,5 +116,19 @@ func NotificationGet(ctx Context, id string) (models.Notification, error) {
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return models.Notification{}, errors.WithStack(err)
 	}
-	return mkNotification.ToNotification(ctx.ProxyServer())
+	n, err := toNotification(ctx, &mkNotification)
+	if err != nil {
+		return n, err
+	}
+	completeStatuses(ctx, []*models.Status{n.Status}, []*models.MkNote{mkNotification.Note})
+	return n, nil
+}
+
+// toNotification converts the Misskey notification, its account has the custom emojis of the server.
+func toNotification(ctx Context, n *models.MkNotification) (models.Notification, error) {
+	r, err := n.ToNotification(ctx.ProxyServer())
+	if err == nil && n.User != nil {
+		n.User.SetLocalEmojis(&r.Account, localEmojis(ctx.ProxyServer()))
+	}
+	return r, err
 }
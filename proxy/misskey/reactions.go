@@ -0,0 +1,132 @@
+package misskey
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// statusReactionsLimit is how many reactions are listed with their accounts, the most Misskey returns.
+const statusReactionsLimit = 100
+
+// ErrReactionRemote is returned for a reaction with a custom emoji of another server, Misskey only reacts with its own.
+var ErrReactionRemote = errors.New("Validation failed: Only the custom emojis of the server can be used as reactions")
+
+// StatusReact reacts to the note with the emoji, Misskey keeps one reaction per user
+// so a different reaction of the user is replaced.
+func StatusReact(ctx Context, id, emoji string) (models.Status, error) {
+	if strings.Contains(models.EmojiReactionName(models.MkReaction(emoji)), "@") {
+		return models.Status{}, ErrReactionRemote
+	}
+	note, err := noteShow(ctx, id)
+	if err != nil {
+		return models.Status{}, err
+	}
+	reaction := models.MkReaction(emoji)
+	if !sameReaction(note.MyReaction, reaction) {
+		if note.MyReaction != "" {
+			if err = noteReactionDelete(ctx, id); err != nil {
+				return models.Status{}, err
+			}
+		}
+		resp, err := client.R().
+			SetBody(makeBody(ctx, utils.Map{"noteId": id, "reaction": reaction})).
+			Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/reactions/create"))
+		if err != nil {
+			return models.Status{}, errors.WithStack(err)
+		}
+		if err = isucceed(resp, http.StatusNoContent, "ALREADY_REACTED"); err != nil {
+			return models.Status{}, errors.WithStack(err)
+		}
+	}
+	return StatusSingle(ctx, id)
+}
+
+// StatusUnreact removes the reaction of the user if it is the emoji.
+func StatusUnreact(ctx Context, id, emoji string) (models.Status, error) {
+	note, err := noteShow(ctx, id)
+	if err != nil {
+		return models.Status{}, err
+	}
+	if sameReaction(note.MyReaction, models.MkReaction(emoji)) {
+		if err = noteReactionDelete(ctx, id); err != nil {
+			return models.Status{}, err
+		}
+	}
+	return StatusSingle(ctx, id)
+}
+
+// sameReaction reports whether the reactions are the same emoji, local custom emojis may have the "@." domain.
+func sameReaction(a, b string) bool {
+	return a != "" && models.EmojiReactionName(a) == models.EmojiReactionName(b)
+}
+
+func noteReactionDelete(ctx Context, id string) error {
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"noteId": id})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/reactions/delete"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusNoContent, "NOT_REACTED"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// StatusReactions returns the reactions of the note with the accounts that reacted,
+// only the reactions with the emoji if it is given.
+func StatusReactions(ctx Context, id, emoji string) ([]models.EmojiReaction, error) {
+	note, err := noteShow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	body := makeBody(ctx, utils.Map{"noteId": id, "limit": statusReactionsLimit})
+	if emoji != "" {
+		body["type"] = models.MkReaction(emoji)
+		for reaction := range note.Reactions {
+			if sameReaction(reaction, models.MkReaction(emoji)) {
+				body["type"] = reaction
+			}
+		}
+	}
+	var result []struct {
+		User models.MkUser `json:"user"`
+		Type string        `json:"type"`
+	}
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/reactions"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	accounts := make(map[string][]models.Account)
+	for _, r := range result {
+		if a, err := userToAccount(ctx, &r.User); err == nil {
+			accounts[r.Type] = append(accounts[r.Type], a)
+		}
+	}
+	var reactions []models.EmojiReaction
+	for _, r := range note.EmojiReactions(localEmojis(ctx.ProxyServer())) {
+		if emoji != "" && !sameReaction(r.Name, models.MkReaction(emoji)) {
+			continue
+		}
+		for reaction, a := range accounts {
+			if sameReaction(reaction, r.Name) {
+				r.Accounts = append(r.Accounts, a...)
+			}
+		}
+		for _, a := range r.Accounts {
+			r.AccountIDs = append(r.AccountIDs, a.ID)
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, nil
+}
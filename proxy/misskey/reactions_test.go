@@ -0,0 +1,17 @@
+package misskey_test
+
+import (
+	"testing"
+
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusReactRemoteEmoji(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	defer f.Close()
+	_, err := misskey.StatusReact(f.Context(), "note", "blobcat@remote.example")
+	assert.ErrorIs(t, err, misskey.ErrReactionRemote)
+	assert.Zero(t, f.Calls("/api/notes/show"))
+	assert.Zero(t, f.Calls("/api/notes/reactions/create"))
+}
@@ -0,0 +1,46 @@
+package misskey
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// RegistryScope is the scope of the Misskey registry the proxy keeps its data in.
+var RegistryScope = []string{"misstodon"}
+
+// RegistryGet decodes the value of the key in the registry scope into result, or returns ErrNotFound.
+func RegistryGet(ctx Context, scope []string, key string, result any) error {
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"scope": scope, "key": key})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/i/registry/get"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		if err = isucceed(resp, http.StatusOK, "NO_SUCH_KEY"); err != nil {
+			return errors.WithStack(err)
+		}
+		return ErrNotFound
+	}
+	if err = json.Unmarshal(resp.Body(), result); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// RegistrySet stores the value of the key in the registry scope.
+func RegistrySet(ctx Context, scope []string, key string, value any) error {
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"scope": scope, "key": key, "value": value})).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/i/registry/set"))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusNoContent); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
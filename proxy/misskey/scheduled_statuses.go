@@ -0,0 +1,358 @@
+package misskey
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ScheduledStatusMinOffset is how far in the future a status must be scheduled, as in Mastodon.
+	ScheduledStatusMinOffset = 5 * time.Minute
+	// ScheduledStatusesTotalLimit is how many statuses a user can have scheduled, as in Mastodon.
+	ScheduledStatusesTotalLimit = 300
+
+	scheduledStatusesPrefix = "scheduled_statuses:"
+	scheduledStatusesIndex  = "scheduled_statuses"
+	schedulerInterval       = 10 * time.Second
+	// A status that cannot be published is retried after schedulerRetryDelay,
+	// and dropped after schedulerMaxAttempts.
+	schedulerRetryDelay  = time.Minute
+	schedulerMaxAttempts = 10
+)
+
+var (
+	ErrScheduledAtTooSoon         = errors.New("Validation failed: Scheduled at The scheduled date must be in the future")
+	ErrScheduledStatusesOverLimit = errors.New("Validation failed: You have exceeded the limit of scheduled statuses")
+)
+
+var (
+	// scheduledStatusesMu serializes the changes of the scheduled statuses,
+	// so a status is not published while it is updated or deleted.
+	scheduledStatusesMu        sync.Mutex
+	scheduledStatusesIndexOnce sync.Once
+	scheduledStatusesIndexErr  error
+)
+
+// scheduledStatusRecord is a scheduled status with what the scheduler needs to publish it.
+// The access token is kept in plaintext until the status is published or deleted,
+// the storage holding it must be protected as the tokens are.
+type scheduledStatusRecord struct {
+	Status    models.ScheduledStatus `json:"status"`
+	Server    string                 `json:"server"`
+	UserID    string                 `json:"user_id"`
+	Token     string                 `json:"token"`
+	PublishAt int64                  `json:"publish_at"`
+	Attempts  int                    `json:"attempts"`
+}
+
+func (r scheduledStatusRecord) key() string {
+	return scheduledStatusesPrefix + storage.Key(r.Server, r.UserID, r.Status.ID)
+}
+
+func (r scheduledStatusRecord) save() error {
+	if err := scheduledStatusesCreateIndex(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return storage.Default.Set(r.key(), string(data), 0)
+}
+
+func scheduledStatusesCreateIndex() error {
+	scheduledStatusesIndexOnce.Do(func() {
+		scheduledStatusesIndexErr = storage.Default.CreateIndex(scheduledStatusesIndex, scheduledStatusesPrefix, "publish_at")
+	})
+	return scheduledStatusesIndexErr
+}
+
+// scheduledStatusesUserPrefix returns the key prefix of the scheduled statuses of the user.
+func scheduledStatusesUserPrefix(ctx Context) (string, error) {
+	key, err := storageKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	return scheduledStatusesPrefix + key + ":", nil
+}
+
+func scheduledStatusRecords(ctx Context) ([]scheduledStatusRecord, error) {
+	prefix, err := scheduledStatusesUserPrefix(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var records []scheduledStatusRecord
+	err = storage.Default.Ascend(prefix, func(_, value string) bool {
+		var r scheduledStatusRecord
+		if json.Unmarshal([]byte(value), &r) == nil {
+			records = append(records, r)
+		}
+		return true
+	})
+	return records, err
+}
+
+func scheduledStatusRecordGet(ctx Context, id string) (r scheduledStatusRecord, err error) {
+	prefix, err := scheduledStatusesUserPrefix(ctx)
+	if err != nil {
+		return
+	}
+	if id == "" || strings.Contains(id, ":") {
+		return r, ErrNotFound
+	}
+	value, err := storage.Default.Get(prefix + id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = ErrNotFound
+		}
+		return
+	}
+	err = json.Unmarshal([]byte(value), &r)
+	return
+}
+
+func scheduledStatusCreate(ctx Context, scheduledAt time.Time, params models.ScheduledStatusParams) (models.ScheduledStatus, error) {
+	status := models.ScheduledStatus{
+		ID:               xid.New().String(),
+		ScheduledAt:      scheduledAt.UTC(),
+		Params:           params,
+		MediaAttachments: []models.MediaAttachment{},
+	}
+	if time.Until(scheduledAt) < ScheduledStatusMinOffset {
+		return status, ErrScheduledAtTooSoon
+	}
+	status.Params.ScheduledAt = &status.ScheduledAt
+	for _, id := range params.MediaIDs {
+		file, err := driveFileShow(ctx, id)
+		if err != nil {
+			return status, errors.WithStack(err)
+		}
+		status.MediaAttachments = append(status.MediaAttachments, file.ToMediaAttachment())
+	}
+
+	scheduledStatusesMu.Lock()
+	defer scheduledStatusesMu.Unlock()
+	records, err := scheduledStatusRecords(ctx)
+	if err != nil {
+		return status, errors.WithStack(err)
+	}
+	if len(records) >= ScheduledStatusesTotalLimit {
+		return status, ErrScheduledStatusesOverLimit
+	}
+	r := scheduledStatusRecord{
+		Status:    status,
+		Server:    ctx.ProxyServer(),
+		UserID:    *ctx.UserID(),
+		Token:     *ctx.Token(),
+		PublishAt: status.ScheduledAt.Unix(),
+	}
+	if err = r.save(); err != nil {
+		return status, errors.WithStack(err)
+	}
+	return status, nil
+}
+
+// ScheduledStatuses returns the scheduled statuses of the user, newest first.
+func ScheduledStatuses(ctx Context, limit int, maxID, sinceID, minID string) ([]models.ScheduledStatus, error) {
+	records, err := scheduledStatusRecords(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The IDs are ordered by creation time.
+	sort.Slice(records, func(i, j int) bool { return records[i].Status.ID > records[j].Status.ID })
+	var statuses []models.ScheduledStatus
+	for _, r := range records {
+		id := r.Status.ID
+		if (maxID != "" && id >= maxID) || (sinceID != "" && id <= sinceID) || (minID != "" && id <= minID) {
+			continue
+		}
+		statuses = append(statuses, r.Status)
+	}
+	if minID != "" && len(statuses) > limit {
+		// min_id pages forward, so the statuses next to it are kept.
+		statuses = statuses[len(statuses)-limit:]
+	}
+	if len(statuses) > limit {
+		statuses = statuses[:limit]
+	}
+	return statuses, nil
+}
+
+func ScheduledStatusGet(ctx Context, id string) (models.ScheduledStatus, error) {
+	r, err := scheduledStatusRecordGet(ctx, id)
+	if err != nil {
+		return models.ScheduledStatus{}, errors.WithStack(err)
+	}
+	return r.Status, nil
+}
+
+// ScheduledStatusUpdate moves the scheduled status to another date.
+func ScheduledStatusUpdate(ctx Context, id string, scheduledAt time.Time) (models.ScheduledStatus, error) {
+	scheduledStatusesMu.Lock()
+	defer scheduledStatusesMu.Unlock()
+	r, err := scheduledStatusRecordGet(ctx, id)
+	if err != nil {
+		return models.ScheduledStatus{}, errors.WithStack(err)
+	}
+	if time.Until(scheduledAt) < ScheduledStatusMinOffset {
+		return r.Status, ErrScheduledAtTooSoon
+	}
+	r.Status.ScheduledAt = scheduledAt.UTC()
+	r.Status.Params.ScheduledAt = &r.Status.ScheduledAt
+	r.PublishAt = r.Status.ScheduledAt.Unix()
+	r.Attempts = 0
+	// The token of the latest request is kept, in case the old one has been revoked.
+	r.Token = *ctx.Token()
+	if err = r.save(); err != nil {
+		return r.Status, errors.WithStack(err)
+	}
+	return r.Status, nil
+}
+
+func ScheduledStatusDelete(ctx Context, id string) error {
+	scheduledStatusesMu.Lock()
+	defer scheduledStatusesMu.Unlock()
+	r, err := scheduledStatusRecordGet(ctx, id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return storage.Default.Delete(r.key())
+}
+
+// RunScheduler publishes the scheduled statuses when they are due, until ctx is done.
+// The statuses are kept in the storage, so the ones that were due while
+// the proxy was down are published when it starts again.
+func RunScheduler(ctx context.Context) error {
+	if err := scheduledStatusesCreateIndex(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		scheduledStatusesPublishDue(time.Now())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func scheduledStatusesPublishDue(now time.Time) {
+	var due []scheduledStatusRecord
+	err := storage.Default.AscendIndex(scheduledStatusesIndex, func(_, value string) bool {
+		var r scheduledStatusRecord
+		if json.Unmarshal([]byte(value), &r) != nil {
+			return true
+		}
+		if r.PublishAt > now.Unix() {
+			return false
+		}
+		due = append(due, r)
+		return true
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read the scheduled statuses")
+		return
+	}
+	for _, r := range due {
+		scheduledStatusPublish(r.key(), now)
+	}
+}
+
+// scheduledStatusPublish posts the scheduled status of the key, unless it has been
+// deleted or moved since it was found due.
+func scheduledStatusPublish(key string, now time.Time) {
+	r, ok := scheduledStatusClaim(key, now)
+	if !ok {
+		return
+	}
+	ctx := misstodon.ContextWithValues(r.Server, r.Token)
+	ctx.SetUserID(r.UserID)
+	params := r.Status.Params
+	var pollOptions []string
+	var pollExpiresIn int
+	var pollMultiple bool
+	if params.Poll != nil {
+		pollOptions = params.Poll.Options
+		pollExpiresIn = params.Poll.ExpiresIn
+		pollMultiple = params.Poll.Multiple
+	}
+	var inReplyToID, channelID, quoteID, spoilerText, language string
+	if params.InReplyToID != nil {
+		inReplyToID = *params.InReplyToID
+	}
+	if params.ChannelID != nil {
+		channelID = *params.ChannelID
+	}
+	if params.QuotedStatusID != nil {
+		quoteID = *params.QuotedStatusID
+	}
+	if params.SpoilerText != nil {
+		spoilerText = *params.SpoilerText
+	}
+	if params.Language != nil {
+		language = *params.Language
+	}
+	_, err := PostNewStatus(ctx,
+		&params.Text, pollOptions, pollExpiresIn, pollMultiple,
+		params.MediaIDs, inReplyToID, channelID, quoteID,
+		params.Sensitive != nil && *params.Sensitive, spoilerText,
+		params.Visibility, language,
+		time.Time{})
+	logger := log.With().Str("server", r.Server).Str("id", r.Status.ID).Logger()
+	scheduledStatusesMu.Lock()
+	defer scheduledStatusesMu.Unlock()
+	if err == nil {
+		_ = storage.Default.Delete(key)
+		logger.Debug().Msg("Scheduled status published")
+		return
+	}
+	if errors.Is(err, ErrUnauthorized) || r.Attempts >= schedulerMaxAttempts {
+		// A status moved during the post is kept for its new date.
+		if current, err := storage.Default.Get(key); err == nil {
+			var moved scheduledStatusRecord
+			if json.Unmarshal([]byte(current), &moved) == nil && moved.PublishAt != r.PublishAt {
+				return
+			}
+		}
+		_ = storage.Default.Delete(key)
+		logger.Warn().Err(err).Int("attempts", r.Attempts).Msg("Scheduled status dropped")
+		return
+	}
+	// The claim already set the retry.
+	logger.Debug().Err(err).Int("attempts", r.Attempts).Msg("Failed to publish the scheduled status")
+}
+
+// scheduledStatusClaim counts an attempt to publish the scheduled status of the key and
+// moves it to the retry date, so the status is not posted twice while the lock is released
+// during the post. It reports false if the status has been deleted or moved since it was found due.
+func scheduledStatusClaim(key string, now time.Time) (r scheduledStatusRecord, ok bool) {
+	scheduledStatusesMu.Lock()
+	defer scheduledStatusesMu.Unlock()
+	value, err := storage.Default.Get(key)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal([]byte(value), &r); err != nil || r.PublishAt > now.Unix() {
+		return
+	}
+	r.Attempts++
+	r.PublishAt = now.Add(schedulerRetryDelay).Unix()
+	if err = r.save(); err != nil {
+		log.Warn().Err(err).Str("server", r.Server).Str("id", r.Status.ID).Msg("Failed to save the scheduled status")
+		return
+	}
+	return r, true
+}
@@ -0,0 +1,131 @@
+package misskey_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scheduleStatus schedules a status with the text at the date.
+func scheduleStatus(t *testing.T, ctx misskey.Context, text string, at time.Time) models.ScheduledStatus {
+	t.Helper()
+	result, err := misskey.PostNewStatus(ctx, &text, nil, 0, false, nil, "", "", "", false, "",
+		models.StatusVisibilityPublic, "", at)
+	require.NoError(t, err)
+	status, ok := result.(models.ScheduledStatus)
+	require.True(t, ok)
+	return status
+}
+
+func TestScheduledStatuses(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	ctx := f.Context()
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	first := scheduleStatus(t, ctx, "first", at)
+	second := scheduleStatus(t, ctx, "second", at)
+	assert.Equal(t, "first", first.Params.Text)
+	assert.True(t, at.Equal(first.ScheduledAt))
+
+	statuses, err := misskey.ScheduledStatuses(ctx, 20, "", "", "")
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, second.ID, statuses[0].ID)
+	assert.Equal(t, first.ID, statuses[1].ID)
+
+	got, err := misskey.ScheduledStatusGet(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first", got.Params.Text)
+
+	moved := at.Add(time.Hour)
+	got, err = misskey.ScheduledStatusUpdate(ctx, first.ID, moved)
+	require.NoError(t, err)
+	assert.True(t, moved.Equal(got.ScheduledAt))
+	_, err = misskey.ScheduledStatusUpdate(ctx, first.ID, time.Now())
+	assert.ErrorIs(t, err, misskey.ErrScheduledAtTooSoon)
+
+	require.NoError(t, misskey.ScheduledStatusDelete(ctx, first.ID))
+	_, err = misskey.ScheduledStatusGet(ctx, first.ID)
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+	assert.ErrorIs(t, misskey.ScheduledStatusDelete(ctx, first.ID), misskey.ErrNotFound)
+
+	text := "too soon"
+	_, err = misskey.PostNewStatus(ctx, &text, nil, 0, false, nil, "", "", "", false, "",
+		models.StatusVisibilityPublic, "", time.Now().Add(time.Minute))
+	assert.ErrorIs(t, err, misskey.ErrScheduledAtTooSoon)
+}
+
+func TestScheduledStatusesOverLimit(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	ctx := f.Context()
+
+	at := time.Now().Add(time.Hour)
+	for i := 0; i < misskey.ScheduledStatusesTotalLimit; i++ {
+		scheduleStatus(t, ctx, "status", at)
+	}
+	text := "one more"
+	_, err := misskey.PostNewStatus(ctx, &text, nil, 0, false, nil, "", "", "", false, "",
+		models.StatusVisibilityPublic, "", at)
+	assert.ErrorIs(t, err, misskey.ErrScheduledStatusesOverLimit)
+}
+
+func TestScheduledStatusesPublishDue(t *testing.T) {
+	var mu sync.Mutex
+	var posted []string
+	fail := true
+	f := newFakeMisskey(t, map[string]fakeHandler{
+		"/api/notes/create": func(body map[string]any) (int, any) {
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				return http.StatusInternalServerError, map[string]any{"error": map[string]any{"code": "INTERNAL_ERROR"}}
+			}
+			posted = append(posted, body["text"].(string))
+			return http.StatusOK, map[string]any{"createdNote": map[string]any{"id": "note", "text": body["text"]}}
+		},
+	})
+	ctx := f.Context()
+
+	now := time.Now()
+	soon := scheduleStatus(t, ctx, "soon", now.Add(10*time.Minute))
+	later := scheduleStatus(t, ctx, "later", now.Add(time.Hour))
+
+	// Nothing is due yet.
+	misskey.ScheduledStatusesPublishDue(now)
+	assert.Zero(t, f.Calls("/api/notes/create"))
+
+	// A failed post is retried later.
+	due := now.Add(15 * time.Minute)
+	misskey.ScheduledStatusesPublishDue(due)
+	assert.Equal(t, 1, f.Calls("/api/notes/create"))
+	_, err := misskey.ScheduledStatusGet(ctx, soon.ID)
+	require.NoError(t, err)
+	misskey.ScheduledStatusesPublishDue(due)
+	assert.Equal(t, 1, f.Calls("/api/notes/create"))
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	misskey.ScheduledStatusesPublishDue(due.Add(2 * time.Minute))
+	mu.Lock()
+	assert.Equal(t, []string{"soon"}, posted)
+	mu.Unlock()
+	_, err = misskey.ScheduledStatusGet(ctx, soon.ID)
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+	_, err = misskey.ScheduledStatusGet(ctx, later.ID)
+	assert.NoError(t, err)
+
+	misskey.ScheduledStatusesPublishDue(now.Add(2 * time.Hour))
+	mu.Lock()
+	assert.Equal(t, []string{"soon", "later"}, posted)
+	mu.Unlock()
+	statuses, err := misskey.ScheduledStatuses(ctx, 20, "", "", "")
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
@@ -24,7 +24,7 @@ func AccountSearch(ctx Context, q string, limit, offset int) ([]models.Account,
 	}
 	var accounts []models.Account
 	for _, u := range result {
-		if a, err := u.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &u); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
@@ -44,9 +44,7 @@ func SearchStatuses(ctx Context, q string, limit, offset int) ([]models.Status,
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return lo.Map(result, func(n models.MkNote, _ int) models.Status {
-		return n.ToStatus(ctx.ProxyServer())
-	}), nil
+	return notesToStatuses(ctx, result), nil
 }
 
 func SearchHashtags(ctx Context, q string, limit, offset int) ([]models.Tag, error) {
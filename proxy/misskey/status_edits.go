@@ -0,0 +1,161 @@
+package misskey
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// ErrEditNotSupported is returned when an edit changes more than Misskey can update.
+var ErrEditNotSupported = errors.New("Only the text and the content warning of a status can be edited")
+
+// statusEditsMu serializes the edits, so no revision is lost from the history.
+var statusEditsMu sync.Mutex
+
+// Misskey does not keep the revisions of a note, the revisions of the notes edited
+// through misstodon are kept in the storage of their author.
+func statusHistoryKey(server, authorID, id string) string {
+	return storage.Key(server, authorID, "status_history", id)
+}
+
+func statusHistory(server, authorID, id string) ([]models.StatusEdit, error) {
+	value, err := storage.Default.Get(statusHistoryKey(server, authorID, id))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var edits []models.StatusEdit
+	err = json.Unmarshal([]byte(value), &edits)
+	return edits, err
+}
+
+// StatusEdit updates the text and the content warning of the note of the user.
+// mediaIDs and pollOptions must be the ones of the status if they are given, Misskey cannot change them.
+func StatusEdit(ctx Context, id string, text *string, sensitive bool, spoilerText string, mediaIDs, pollOptions []string) (models.Status, error) {
+	if _, ok := models.ChatMessageID(id); ok {
+		return models.Status{}, ErrEditNotSupported
+	}
+	// The history is kept under the user ID of the token, which is checked.
+	if _, err := storageKey(ctx); err != nil {
+		return models.Status{}, err
+	}
+	statusEditsMu.Lock()
+	defer statusEditsMu.Unlock()
+	current, err := StatusSingle(ctx, id)
+	if err != nil {
+		return current, err
+	}
+	if current.Account.ID != *ctx.UserID() {
+		return current, ErrUnauthorized
+	}
+	if mediaIDs != nil {
+		var currentIDs []string
+		for _, m := range current.MediaAttachments {
+			currentIDs = append(currentIDs, m.ID)
+		}
+		if !slices.Equal(mediaIDs, currentIDs) {
+			return current, ErrEditNotSupported
+		}
+	}
+	if pollOptions != nil {
+		var currentOptions []string
+		if current.Poll != nil {
+			for _, o := range current.Poll.Options {
+				currentOptions = append(currentOptions, o.Title)
+			}
+		}
+		if !slices.Equal(pollOptions, currentOptions) {
+			return current, ErrEditNotSupported
+		}
+	}
+
+	if text == nil {
+		// Misskey needs the text, the text of the note is kept.
+		source, err := StatusSource(ctx, id)
+		if err != nil {
+			return current, err
+		}
+		text = &source.Text
+	}
+	body := makeBody(ctx, utils.Map{"noteId": id, "text": *text, "cw": nil})
+	if sensitive || spoilerText != "" {
+		body["cw"] = spoilerText
+		if spoilerText == "" {
+			body["cw"] = "Sensitive"
+		}
+	}
+	resp, err := client.R().
+		SetBody(body).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/update"))
+	if err != nil {
+		return current, errors.WithStack(err)
+	}
+	// The note has been found, so a missing endpoint is what is not found.
+	if resp.StatusCode() == http.StatusNotFound {
+		return current, ErrNotSupported
+	}
+	if err = isucceed(resp, http.StatusNoContent); err != nil {
+		return current, errors.WithStack(err)
+	}
+
+	edits, err := statusHistory(ctx.ProxyServer(), current.Account.ID, id)
+	if err != nil {
+		return current, errors.WithStack(err)
+	}
+	edits = append(edits, current.ToStatusEdit())
+	data, err := json.Marshal(edits)
+	if err != nil {
+		return current, errors.WithStack(err)
+	}
+	if err = storage.Default.Set(statusHistoryKey(ctx.ProxyServer(), current.Account.ID, id), string(data), 0); err != nil {
+		return current, errors.WithStack(err)
+	}
+	return StatusSingle(ctx, id)
+}
+
+// StatusHistory returns the revisions of the status, oldest first, the last one is the current status.
+func StatusHistory(ctx Context, id string) ([]models.StatusEdit, error) {
+	status, err := StatusSingle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	edits, err := statusHistory(ctx.ProxyServer(), status.Account.ID, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(edits, status.ToStatusEdit()), nil
+}
+
+// StatusSource returns the MFM text of the status, as the user wrote it.
+func StatusSource(ctx Context, id string) (models.StatusSource, error) {
+	source := models.StatusSource{ID: id}
+	if messageID, ok := models.ChatMessageID(id); ok {
+		message, err := chatMessageShow(ctx, messageID)
+		if err != nil {
+			return source, err
+		}
+		if message.Text != nil {
+			source.Text = *message.Text
+		}
+		return source, nil
+	}
+	note, err := noteShow(ctx, id)
+	if err != nil {
+		return source, err
+	}
+	if note.Text != nil {
+		source.Text = *note.Text
+	}
+	if note.Cw != nil {
+		source.SpoilerText = *note.Cw
+	}
+	return source, nil
+}
@@ -2,6 +2,9 @@ package misskey
 
 import (
 	"net/http"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gizmo-ds/misstodon/internal/utils"
@@ -10,20 +13,15 @@ import (
 )
 
 func StatusSingle(ctx Context, statusID string) (models.Status, error) {
+	if id, ok := models.ChatMessageID(statusID); ok {
+		return chatStatus(ctx, id)
+	}
 	var status models.Status
-	var note models.MkNote
-	body := makeBody(ctx, utils.Map{"noteId": statusID})
-	resp, err := client.R().
-		SetBody(body).
-		SetResult(&note).
-		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/show"))
+	note, err := noteShow(ctx, statusID)
 	if err != nil {
-		return status, errors.WithStack(err)
-	}
-	if err = isucceed(resp, 200); err != nil {
-		return status, errors.WithStack(err)
+		return status, err
 	}
-	status = note.ToStatus(ctx.ProxyServer())
+	status = noteToStatus(ctx, note)
 	if ctx.Token() != nil {
 		state, err := getNoteState(ctx.ProxyServer(), *ctx.Token(), status.ID)
 		if err != nil {
@@ -35,6 +33,21 @@ func StatusSingle(ctx Context, statusID string) (models.Status, error) {
 	return status, err
 }
 
+func noteShow(ctx Context, id string) (models.MkNote, error) {
+	var note models.MkNote
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"noteId": id})).
+		SetResult(&note).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/show"))
+	if err != nil {
+		return note, errors.WithStack(err)
+	}
+	if err = isucceedNotFound(resp, "NO_SUCH_NOTE"); err != nil {
+		return note, errors.WithStack(err)
+	}
+	return note, nil
+}
+
 type noteState struct {
 	IsFavorited   bool `json:"isFavorited"`
 	IsMutedThread bool `json:"isMutedThread"`
@@ -158,21 +171,69 @@ func StatusBookmarks(ctx Context,
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return nil, errors.WithStack(err)
 	}
-	var status []models.Status
+	var notes []models.MkNote
 	for _, s := range result {
-		status = append(status, s.Note.ToStatus(ctx.ProxyServer()))
+		notes = append(notes, s.Note)
 	}
-	return status, nil
+	return notesToStatuses(ctx, notes), nil
 }
 
+// ErrQuoteEmpty is returned for a quote with neither text, content warning, media nor poll,
+// Misskey would post it as a boost.
+var ErrQuoteEmpty = errors.New("Validation failed: Text can't be blank")
+
 // PostNewStatus 发送新的 Status
 func PostNewStatus(ctx Context,
 	status *string, pollOptions []string, pollExpiresIn int, pollMultiple bool,
-	MediaIDs []string, InReplyToID string,
+	MediaIDs []string, InReplyToID, ChannelID, QuoteID string,
 	Sensitive bool, SpoilerText string,
 	Visibility models.StatusVisibility, Language string,
 	ScheduledAt time.Time,
 ) (any, error) {
+	if messageID, ok := models.ChatMessageID(InReplyToID); ok && ScheduledAt.IsZero() {
+		// Replies to chat messages are posted to the chat.
+		var text string
+		if status != nil {
+			text = *status
+		}
+		return chatReply(ctx, messageID, text, MediaIDs)
+	}
+	if QuoteID != "" && (status == nil || *status == "") && !Sensitive && len(MediaIDs) == 0 && len(pollOptions) < 2 {
+		return nil, ErrQuoteEmpty
+	}
+	if !ScheduledAt.IsZero() {
+		params := models.ScheduledStatusParams{
+			MediaIDs:   utils.SliceIfNull(MediaIDs),
+			Sensitive:  &Sensitive,
+			Visibility: Visibility,
+		}
+		if status != nil {
+			params.Text = *status
+		}
+		if len(pollOptions) >= 2 {
+			params.Poll = &models.ScheduledStatusPollParams{
+				Options:   pollOptions,
+				ExpiresIn: pollExpiresIn,
+				Multiple:  pollMultiple,
+			}
+		}
+		if InReplyToID != "" {
+			params.InReplyToID = &InReplyToID
+		}
+		if ChannelID != "" {
+			params.ChannelID = &ChannelID
+		}
+		if QuoteID != "" {
+			params.QuotedStatusID = &QuoteID
+		}
+		if SpoilerText != "" {
+			params.SpoilerText = &SpoilerText
+		}
+		if Language != "" {
+			params.Language = &Language
+		}
+		return scheduledStatusCreate(ctx, ScheduledAt, params)
+	}
 	body := makeBody(ctx, utils.Map{"localOnly": false})
 	var noteMentions []string
 	if status != nil && *status != "" {
@@ -223,6 +284,16 @@ func PostNewStatus(ctx Context,
 	if InReplyToID != "" {
 		body["replyId"] = InReplyToID
 	}
+	if ChannelID != "" {
+		// The pseudo-list ID of a channel is accepted as well.
+		if id, ok := models.ChannelID(ChannelID); ok {
+			ChannelID = id
+		}
+		body["channelId"] = ChannelID
+	}
+	if QuoteID != "" {
+		body["renoteId"] = QuoteID
+	}
 	var result struct {
 		CreatedNote models.MkNote `json:"createdNote"`
 	}
@@ -236,10 +307,13 @@ func PostNewStatus(ctx Context,
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return result.CreatedNote.ToStatus(ctx.ProxyServer()), nil
+	return noteToStatus(ctx, result.CreatedNote), nil
 }
 
 func StatusDelete(ctx Context, id string) error {
+	if messageID, ok := models.ChatMessageID(id); ok {
+		return chatMessageDelete(ctx, messageID)
+	}
 	resp, err := client.R().
 		SetBody(makeBody(ctx, utils.Map{"noteId": id})).
 		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/delete"))
@@ -274,7 +348,7 @@ func StatusReblog(ctx Context, id string) (models.Status, error) {
 	if err = isucceed(resp, http.StatusOK); err != nil {
 		return models.Status{}, errors.WithStack(err)
 	}
-	status := result.CreatedNote.ToStatus(ctx.ProxyServer())
+	status := noteToStatus(ctx, result.CreatedNote)
 	status.ReBlog = &original
 	status.ReBlogged = true
 	return status, nil
@@ -320,7 +394,7 @@ func StatusRebloggedBy(ctx Context, id string, limit int, sinceID, maxID string)
 	var accounts []models.Account
 	for _, note := range result {
 		if note.User != nil {
-			if a, err := note.User.ToAccount(ctx.ProxyServer()); err == nil {
+			if a, err := userToAccount(ctx, note.User); err == nil {
 				accounts = append(accounts, a)
 			}
 		}
@@ -355,56 +429,162 @@ func StatusFavouritedBy(ctx Context, id string, limit int, sinceID, maxID string
 	}
 	var accounts []models.Account
 	for _, r := range result {
-		if a, err := r.User.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &r.User); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
 	return accounts, nil
 }
 
+// StatusContextLimit is how many ancestors and how many descendants the context of a status has at most.
+var StatusContextLimit = 60
+
+const (
+	// statusContextPageSize is how many notes are fetched at once, the most Misskey returns.
+	statusContextPageSize = 100
+	// statusContextConcurrency is how many replies are fetched at once for the context of a status.
+	statusContextConcurrency = 4
+)
+
 func StatusContext(ctx Context, id string) (map[string]any, error) {
-	result := map[string]any{
-		"ancestors":   []models.Status{},
-		"descendants": []models.Status{},
+	if messageID, ok := models.ChatMessageID(id); ok {
+		return chatContext(ctx, messageID)
+	}
+	ancestorNotes, err := noteAncestors(ctx, id, StatusContextLimit)
+	if err != nil {
+		return nil, err
 	}
+	descendantNotes, err := noteDescendants(ctx, id, StatusContextLimit)
+	if err != nil {
+		return nil, err
+	}
+	ancestors := FiltersApply(ctx, models.FilterContextThread, notesToStatuses(ctx, ancestorNotes))
+	descendants := FiltersApply(ctx, models.FilterContextThread, notesToStatuses(ctx, descendantNotes))
+	return map[string]any{
+		"ancestors":   utils.SliceIfNull(ancestors),
+		"descendants": utils.SliceIfNull(descendants),
+	}, nil
+}
 
-	// Get ancestors via /api/notes/conversation
-	var ancestorNotes []models.MkNote
-	resp, err := client.R().
-		SetBody(makeBody(ctx, utils.Map{"noteId": id, "limit": 40})).
-		SetResult(&ancestorNotes).
-		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/conversation"))
-	if err == nil && isucceed(resp, http.StatusOK) == nil {
-		var ancestors []models.Status
-		for _, n := range ancestorNotes {
-			ancestors = append(ancestors, n.ToStatus(ctx.ProxyServer()))
+// noteAncestors returns the notes the note replies to, the root first.
+func noteAncestors(ctx Context, id string, limit int) ([]models.MkNote, error) {
+	var notes []models.MkNote
+	for len(notes) < limit {
+		var page []models.MkNote
+		resp, err := client.R().
+			SetBody(makeBody(ctx, utils.Map{
+				"noteId": id,
+				"limit":  min(statusContextPageSize, limit-len(notes)),
+				"offset": len(notes),
+			})).
+			SetResult(&page).
+			Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/conversation"))
+		if err != nil {
+			return nil, errors.WithStack(err)
 		}
-		// Reverse to chronological order
-		for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
-			ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+		if err = isucceedNotFound(resp, "NO_SUCH_NOTE"); err != nil {
+			return nil, errors.WithStack(err)
 		}
-		if len(ancestors) > 0 {
-			result["ancestors"] = ancestors
+		notes = append(notes, page...)
+		if len(page) < statusContextPageSize {
+			break
 		}
 	}
+	// Misskey returns the nearest ancestor first.
+	slices.Reverse(notes)
+	return notes, nil
+}
 
-	// Get descendants via /api/notes/children
-	var childNotes []models.MkNote
-	resp, err = client.R().
-		SetBody(makeBody(ctx, utils.Map{"noteId": id, "limit": 40})).
-		SetResult(&childNotes).
-		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/children"))
-	if err == nil && isucceed(resp, http.StatusOK) == nil {
-		var descendants []models.Status
-		for _, n := range childNotes {
-			descendants = append(descendants, n.ToStatus(ctx.ProxyServer()))
+// noteDescendants returns the replies to the note and their replies, depth-first with the oldest
+// replies first as in Mastodon. The tree is fetched one level at a time, the replies of a level
+// are fetched concurrently, until there are limit notes.
+func noteDescendants(ctx Context, id string, limit int) ([]models.MkNote, error) {
+	children := make(map[string][]models.MkNote)
+	level := []string{id}
+	for count := 0; len(level) > 0 && count < limit; {
+		// Each note of the level has replies, so no more notes than the remaining ones are fetched.
+		level = level[:min(len(level), limit-count)]
+		replies := make([][]models.MkNote, len(level))
+		errs := make([]error, len(level))
+		sem := make(chan struct{}, statusContextConcurrency)
+		var wg sync.WaitGroup
+		for i, parent := range level {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				replies[i], errs[i] = noteReplies(ctx, parent, limit-count)
+			}()
+		}
+		wg.Wait()
+		var next []string
+		for i, parent := range level {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			for _, n := range replies[i] {
+				if count >= limit {
+					break
+				}
+				children[parent] = append(children[parent], n)
+				count++
+				if n.RepliesCount > 0 {
+					next = append(next, n.ID)
+				}
+			}
 		}
-		if len(descendants) > 0 {
-			result["descendants"] = descendants
+		level = next
+	}
+	var notes []models.MkNote
+	var walk func(id string)
+	walk = func(id string) {
+		for _, n := range children[id] {
+			notes = append(notes, n)
+			walk(n.ID)
 		}
 	}
+	walk(id)
+	return notes, nil
+}
 
-	return result, nil
+// noteReplies returns at most limit direct replies to the note, oldest first.
+func noteReplies(ctx Context, id string, limit int) ([]models.MkNote, error) {
+	var replies []models.MkNote
+	// Misskey returns the newest notes first, unless there is a sinceId. "0" is before every ID.
+	sinceID := "0"
+	for len(replies) < limit {
+		body := makeBody(ctx, utils.Map{"noteId": id, "limit": statusContextPageSize, "sinceId": sinceID})
+		var page []models.MkNote
+		resp, err := client.R().
+			SetBody(body).
+			SetResult(&page).
+			Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/children"))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err = isucceedNotFound(resp, "NO_SUCH_NOTE"); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		slices.SortFunc(page, func(a, b models.MkNote) int { return strings.Compare(a.ID, b.ID) })
+		sinceID = page[len(page)-1].ID
+		for _, n := range page {
+			// The children of a note are also the quotes of it, they are not replies.
+			if n.ReplyID != nil && *n.ReplyID == id {
+				replies = append(replies, n)
+			}
+		}
+		if len(page) < statusContextPageSize {
+			break
+		}
+	}
+	if len(replies) > limit {
+		replies = replies[:limit]
+	}
+	return replies, nil
 }
 
 func StatusMuteThread(ctx Context, id string) (models.Status, error) {
@@ -519,9 +699,61 @@ func SearchStatusByHashtag(ctx Context,
 	if err != nil {
 		return nil, err
 	}
-	var list []models.Status
-	for _, note := range result {
-		list = append(list, note.ToStatus(ctx.ProxyServer()))
+	return notesToStatuses(ctx, result), nil
+}
+
+// eachStatusNote calls fn with the status and the note it was converted from,
+// then with the status and the note they renote or quote.
+func eachStatusNote(s *models.Status, n *models.MkNote, fn func(*models.Status, *models.MkNote)) {
+	for s != nil && n != nil {
+		fn(s, n)
+		if s.Quote != nil {
+			s = s.Quote.QuotedStatus
+		} else {
+			s = s.ReBlog
+		}
+		n = n.ReNote
+	}
+}
+
+// completeStatuses fills what the notes do not have, the mentioned accounts, the link previews
+// and the custom emojis of the server, of the statuses converted from the notes.
+// They are looked up once for all the statuses.
+func completeStatuses(ctx Context, statuses []*models.Status, notes []*models.MkNote) {
+	var userIDs, links []string
+	for i := range statuses {
+		eachStatusNote(statuses[i], notes[i], func(_ *models.Status, n *models.MkNote) {
+			userIDs = append(userIDs, n.Mentions...)
+			if link := n.LinkUrl(); link != "" {
+				links = append(links, link)
+			}
+		})
+	}
+	mentions := statusMentions(ctx, userIDs)
+	cards := linkCards(ctx.ProxyServer(), links)
+	emojis := localEmojis(ctx.ProxyServer())
+	for i := range statuses {
+		eachStatusNote(statuses[i], notes[i], func(s *models.Status, n *models.MkNote) {
+			setStatusMentions(s, n, mentions)
+			s.Card = cards[n.LinkUrl()]
+			n.SetLocalEmojis(s, emojis)
+		})
 	}
-	return list, nil
+}
+
+// notesToStatuses converts the notes to statuses with their mentions and link previews.
+func notesToStatuses(ctx Context, notes []models.MkNote) []models.Status {
+	statuses := make([]models.Status, len(notes))
+	ptrs := make([]*models.Status, len(notes))
+	notePtrs := make([]*models.MkNote, len(notes))
+	for i := range notes {
+		statuses[i] = notes[i].ToStatus(ctx.ProxyServer())
+		ptrs[i], notePtrs[i] = &statuses[i], &notes[i]
+	}
+	completeStatuses(ctx, ptrs, notePtrs)
+	return statuses
+}
+
+func noteToStatus(ctx Context, note models.MkNote) models.Status {
+	return notesToStatuses(ctx, []models.MkNote{note})[0]
 }
@@ -0,0 +1,121 @@
+package misskey_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeThread answers notes/conversation and notes/children for a thread, replies maps a note to its parent.
+func fakeThread(replies map[string]string) map[string]fakeHandler {
+	children := func(id string) []models.MkNote {
+		var notes []models.MkNote
+		for child, parent := range replies {
+			if parent == id {
+				count := 0
+				for _, p := range replies {
+					if p == child {
+						count++
+					}
+				}
+				parent := parent
+				notes = append(notes, models.MkNote{ID: child, ReplyID: &parent, RepliesCount: count})
+			}
+		}
+		return notes
+	}
+	return map[string]fakeHandler{
+		"/api/notes/conversation": func(body map[string]any) (int, any) {
+			if body["noteId"] == "missing" {
+				return http.StatusBadRequest, map[string]any{"error": map[string]any{"code": "NO_SUCH_NOTE"}}
+			}
+			var notes []models.MkNote
+			for id := replies[body["noteId"].(string)]; id != ""; id = replies[id] {
+				notes = append(notes, models.MkNote{ID: id})
+			}
+			return http.StatusOK, notes
+		},
+		"/api/notes/children": func(body map[string]any) (int, any) {
+			notes := children(body["noteId"].(string))
+			sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+			var page []models.MkNote
+			for _, n := range notes {
+				if n.ID > body["sinceId"].(string) && len(page) < int(body["limit"].(float64)) {
+					page = append(page, n)
+				}
+			}
+			return http.StatusOK, page
+		},
+	}
+}
+
+func statusIDs(statuses any) []string {
+	var ids []string
+	for _, s := range statuses.([]models.Status) {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestStatusContext(t *testing.T) {
+	replies := map[string]string{"b": "a", "c": "b", "c1": "c", "c2": "c", "c1a": "c1", "c1b": "c1", "c2a": "c2"}
+	f := newFakeMisskey(t, fakeThread(replies))
+	result, err := misskey.StatusContext(f.Context(), "c")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, statusIDs(result["ancestors"]))
+	// The descendants are depth-first, the oldest replies first.
+	assert.Equal(t, []string{"c1", "c1a", "c1b", "c2", "c2a"}, statusIDs(result["descendants"]))
+
+	_, err = misskey.StatusContext(f.Context(), "missing")
+	assert.ErrorIs(t, err, misskey.ErrNotFound)
+}
+
+func TestStatusContextLimit(t *testing.T) {
+	replies := map[string]string{}
+	for i := 0; i < 150; i++ {
+		replies[fmt.Sprintf("r%03d", i)] = "root"
+		replies[fmt.Sprintf("r%03da", i)] = fmt.Sprintf("r%03d", i)
+	}
+	f := newFakeMisskey(t, fakeThread(replies))
+
+	defer func(limit int) { misskey.StatusContextLimit = limit }(misskey.StatusContextLimit)
+	misskey.StatusContextLimit = 300
+	result, err := misskey.StatusContext(f.Context(), "root")
+	require.NoError(t, err)
+	ids := statusIDs(result["descendants"])
+	// The replies are fetched across pages.
+	assert.Len(t, ids, 300)
+	assert.Equal(t, []string{"r000", "r000a", "r001", "r001a"}, ids[:4])
+
+	misskey.StatusContextLimit = 10
+	calls := f.Calls("/api/notes/children")
+	result, err = misskey.StatusContext(f.Context(), "root")
+	require.NoError(t, err)
+	assert.Len(t, statusIDs(result["descendants"]), 10)
+	assert.LessOrEqual(t, f.Calls("/api/notes/children")-calls, 11)
+}
+
+func TestPostNewStatusEmptyChatReply(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	text := "@alice@example.com "
+	_, err := misskey.PostNewStatus(f.Context(), &text, nil, 0, false, nil, models.ChatStatusIDPrefix+"message", "", "",
+		false, "", models.StatusVisibilityDirect, "", time.Time{})
+	assert.ErrorIs(t, err, misskey.ErrChatReplyEmpty)
+	assert.Zero(t, f.Calls("/api/chat/messages/show"))
+}
+
+func TestPostNewStatusEmptyQuote(t *testing.T) {
+	f := newFakeMisskey(t, nil)
+	text := ""
+	_, err := misskey.PostNewStatus(f.Context(), &text, nil, 0, false, nil, "", "", "quoted",
+		false, "", models.StatusVisibilityPublic, "", time.Time{})
+	assert.ErrorIs(t, err, misskey.ErrQuoteEmpty)
+	assert.Zero(t, f.Calls("/api/notes/create"))
+}
@@ -0,0 +1,63 @@
+package misskey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/storage"
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// storageUserTTL is how long the owner of a token is remembered.
+const storageUserTTL = time.Hour
+
+// storageKey returns the storage key of the proxy-side state of the user.
+// The user ID of the access token is checked against Misskey, so a forged
+// user ID cannot reach the state of another user.
+func storageKey(ctx Context, parts ...string) (string, error) {
+	userID := ctx.UserID()
+	if userID == nil || *userID == "" {
+		return "", ErrUnauthorized
+	}
+	owner, err := tokenOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+	if owner != *userID {
+		return "", ErrUnauthorized
+	}
+	return storage.Key(ctx.ProxyServer(), *userID, parts...), nil
+}
+
+// tokenOwner returns the ID of the user of the access token, it is remembered for storageUserTTL.
+func tokenOwner(ctx Context) (string, error) {
+	token := ctx.Token()
+	if token == nil || *token == "" {
+		return "", ErrUnauthorized
+	}
+	sum := sha256.Sum256([]byte(*token))
+	tokenKey := storage.Key(ctx.ProxyServer(), "tokens", hex.EncodeToString(sum[:]))
+	if owner, err := storage.Default.Get(tokenKey); err == nil {
+		return owner, nil
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/i"))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err = storage.Default.Set(tokenKey, result.ID, storageUserTTL); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return result.ID, nil
+}
@@ -0,0 +1,91 @@
+package misskey
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
+)
+
+// StreamEvent converts the message of the Misskey streaming API to a Mastodon streaming event,
+// the statuses are completed as the statuses of the REST API are.
+func StreamEvent(ctx Context, m models.MkStreamMessage) (models.StreamEvent, bool) {
+	body, err := m.ParseBody()
+	if err != nil || m.Type != models.MkStreamMessageTypeChannel {
+		return m.ToStreamEvent(ctx.ProxyServer())
+	}
+	switch body.Type {
+	case "note":
+		var note models.MkNote
+		if err = json.Unmarshal(body.Body, &note); err != nil {
+			return models.StreamEvent{}, false
+		}
+		return newStreamEvent(models.StreamEventTypeUpdate, noteToStatus(ctx, note))
+	case "notification":
+		var n models.MkNotification
+		if err = json.Unmarshal(body.Body, &n); err != nil {
+			return models.StreamEvent{}, false
+		}
+		notification, err := toNotification(ctx, &n)
+		if err != nil || notification.Type == models.NotificationTypeUnknown {
+			return models.StreamEvent{}, false
+		}
+		completeStatuses(ctx, []*models.Status{notification.Status}, []*models.MkNote{n.Note})
+		return newStreamEvent(models.StreamEventTypeNotification, notification)
+	}
+	return m.ToStreamEvent(ctx.ProxyServer())
+}
+
+// StreamConversation returns the conversation of a direct note received from the streaming API,
+// its accounts are the participants other than the user, as in Conversations.
+func StreamConversation(ctx Context, note models.MkNote) (models.StreamEvent, bool) {
+	userID, err := tokenOwner(ctx)
+	if err != nil {
+		return models.StreamEvent{}, false
+	}
+	c := note.ToConversation(ctx.ProxyServer(), userID)
+	var others []string
+	for _, p := range note.Participants() {
+		if p != userID {
+			others = append(others, p)
+		}
+	}
+	if len(others) > 0 {
+		if accounts, err := usersShow(ctx, others); err == nil {
+			c.Accounts = accounts
+		}
+	}
+	completeStatuses(ctx, []*models.Status{c.LastStatus}, []*models.MkNote{&note})
+	return newStreamEvent(models.StreamEventTypeConversation, c)
+}
+
+// StreamDirectNotes returns the direct notes the user received after sinceID, at most limit of them,
+// the direct stream polls them when the streaming API is unreachable.
+func StreamDirectNotes(ctx Context, limit int, sinceID string) ([]models.MkNote, error) {
+	body := makeBody(ctx, utils.Map{"visibility": models.MkNoteVisibilitySpecif, "limit": limit})
+	if sinceID != "" {
+		body["sinceId"] = sinceID
+	}
+	var result []models.MkNote
+	resp, err := client.R().
+		SetBody(body).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/mentions"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func newStreamEvent(event models.StreamEventType, payload any) (models.StreamEvent, bool) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.StreamEvent{}, false
+	}
+	return models.StreamEvent{Event: event, Payload: string(data)}, true
+}
@@ -0,0 +1,56 @@
+package misskey_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamConversation(t *testing.T) {
+	f := newFakeMisskey(t, map[string]fakeHandler{
+		"/api/users/show": func(body map[string]any) (int, any) {
+			var users []map[string]any
+			for _, id := range body["userIds"].([]any) {
+				users = append(users, map[string]any{"id": id, "username": id, "createdAt": time.Now().Format(time.RFC3339)})
+			}
+			return http.StatusOK, users
+		},
+	})
+	// The streaming API only knows the token.
+	ctx := misstodon.ContextWithValues(f.Host(), fakeToken)
+
+	for _, tt := range []struct {
+		name   string
+		author string
+		to     []string
+		unread bool
+	}{
+		{"sent", fakeUserID, []string{"bob"}, false},
+		{"received", "bob", []string{fakeUserID}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			note := models.MkNote{
+				ID:             "note",
+				UserId:         tt.author,
+				User:           &models.MkUser{ID: tt.author, Username: tt.author},
+				Visibility:     models.MkNoteVisibilitySpecif,
+				VisibleUserIds: tt.to,
+			}
+			event, ok := misskey.StreamConversation(ctx, note)
+			require.True(t, ok)
+			assert.Equal(t, models.StreamEventTypeConversation, event.Event)
+			var c models.Conversation
+			require.NoError(t, json.Unmarshal([]byte(event.Payload), &c))
+			assert.Equal(t, tt.unread, c.Unread)
+			require.Len(t, c.Accounts, 1)
+			assert.Equal(t, "bob", c.Accounts[0].ID)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package streaming
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/models"
+)
+
+// clientBufferSize is the number of events buffered for a client before events are dropped.
+const clientBufferSize = 64
+
+// Hub shares one Misskey streaming connection between all clients of the same server and token.
+type Hub struct {
+	// PollingFallback enables polling the REST API when the streaming API is unreachable.
+	PollingFallback bool
+	PollInterval    time.Duration
+
+	mu        sync.Mutex
+	upstreams map[string]*upstream
+}
+
+var DefaultHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{
+		PollInterval: DefaultPollInterval,
+		upstreams:    make(map[string]*upstream),
+	}
+}
+
+// Client is a downstream consumer of a shared Misskey streaming connection.
+type Client struct {
+	upstream  *upstream
+	events    chan models.StreamEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Connect returns a client of the connection for server and token, dialing it if needed.
+// It fails if the first connection attempt fails, later disconnections are retried in the background.
+func (h *Hub) Connect(ctx context.Context, server, token string) (*Client, error) {
+	key := server + " " + token
+	h.mu.Lock()
+	u, ok := h.upstreams[key]
+	if !ok {
+		u = newUpstream(h, key, server, token)
+		h.upstreams[key] = u
+		go u.run()
+	}
+	c := &Client{
+		upstream: u,
+		events:   make(chan models.StreamEvent, clientBufferSize),
+		done:     make(chan struct{}),
+	}
+	u.mu.Lock()
+	u.clients[c] = struct{}{}
+	u.mu.Unlock()
+	h.mu.Unlock()
+
+	select {
+	case <-u.ready:
+	case <-ctx.Done():
+		c.Close()
+		return nil, ctx.Err()
+	}
+	if u.err != nil {
+		c.Close()
+		return nil, u.err
+	}
+	return c, nil
+}
+
+func (h *Hub) remove(u *upstream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.upstreams[u.key] == u {
+		delete(h.upstreams, u.key)
+	}
+}
+
+// Subscribe adds the stream to the events of the client.
+func (c *Client) Subscribe(s Subscription) error {
+	return c.upstream.subscribe(c, s)
+}
+
+// Unsubscribe removes the stream from the events of the client.
+func (c *Client) Unsubscribe(s Subscription) {
+	c.upstream.mu.Lock()
+	defer c.upstream.mu.Unlock()
+	c.upstream.unsubscribe(c, s)
+}
+
+// Events returns the channel the events of the subscribed streams are sent to.
+func (c *Client) Events() <-chan models.StreamEvent {
+	return c.events
+}
+
+// Done is closed when the client is closed or the connection is given up.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close releases the client, the shared connection is closed with its last client.
+func (c *Client) Close() {
+	u := c.upstream
+	u.hub.mu.Lock()
+	defer u.hub.mu.Unlock()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.clients[c]; !ok {
+		return
+	}
+	delete(u.clients, c)
+	for _, sub := range u.subscriptions {
+		if _, ok := sub.clients[c]; ok {
+			u.unsubscribe(c, sub.Subscription)
+		}
+	}
+	c.closeDone()
+	if len(u.clients) == 0 {
+		if u.hub.upstreams[u.key] == u {
+			delete(u.hub.upstreams, u.key)
+		}
+		u.cancel()
+	}
+}
+
+func (c *Client) closeDone() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
@@ -0,0 +1,184 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrPollingUnsupported = errors.New("stream is not available while polling")
+
+const (
+	DefaultPollInterval = 10 * time.Second
+	pollLimit           = 20
+)
+
+// pollItem is an item of a source, its payload is nil if it is not sent to the stream.
+type pollItem struct {
+	id      string
+	payload any
+}
+
+// pollSource fetches the items created after the cursor, at most limit of them.
+// seeded is set once the source has been fetched, the cursor stays empty while it has no items.
+type pollSource struct {
+	event  models.StreamEventType
+	fetch  func(sinceID string, limit int) ([]pollItem, error)
+	cursor string
+	seeded bool
+}
+
+// statusSource polls statuses, keep reports whether a status belongs to the stream.
+func statusSource(fetch func(sinceID string, limit int) ([]models.Status, error), keep func(models.Status) bool) *pollSource {
+	return &pollSource{
+		event: models.StreamEventTypeUpdate,
+		fetch: func(sinceID string, limit int) ([]pollItem, error) {
+			list, err := fetch(sinceID, limit)
+			items := make([]pollItem, 0, len(list))
+			for _, s := range list {
+				// The cursor moves past the statuses that are not kept.
+				var payload any
+				if keep == nil || keep(s) {
+					payload = s
+				}
+				items = append(items, pollItem{id: s.ID, payload: payload})
+			}
+			return items, err
+		},
+	}
+}
+
+// isLocal reports whether the author of the status is a user of the server.
+func isLocal(s models.Status) bool {
+	return !strings.Contains(s.Account.Acct, "@")
+}
+
+// pollSources returns the sources polled for the subscription.
+func (u *upstream) pollSources(s Subscription) ([]*pollSource, error) {
+	ctx := misstodon.ContextWithValues(u.server, u.token)
+	notifications := &pollSource{
+		event: models.StreamEventTypeNotification,
+		fetch: func(sinceID string, limit int) ([]pollItem, error) {
+			list, err := misskey.NotificationsGet(ctx, limit, sinceID, "", "", nil, nil, "")
+			items := make([]pollItem, 0, len(list))
+			for _, n := range list {
+				items = append(items, pollItem{id: n.Id, payload: n})
+			}
+			return items, err
+		},
+	}
+	switch s.Stream {
+	case StreamUser:
+		home := statusSource(func(sinceID string, limit int) ([]models.Status, error) {
+			return misskey.TimelineHome(ctx, limit, "", sinceID)
+		}, nil)
+		return []*pollSource{home, notifications}, nil
+	case StreamUserNotification:
+		return []*pollSource{notifications}, nil
+	case StreamPublic, StreamPublicRemote, StreamPublicLocal:
+		timeline, keep := models.TimelinePublicTypeRemote, func(s models.Status) bool { return !isLocal(s) }
+		switch s.Stream {
+		case StreamPublic:
+			keep = nil
+		case StreamPublicLocal:
+			timeline, keep = models.TimelinePublicTypeLocal, nil
+		}
+		return []*pollSource{statusSource(func(sinceID string, limit int) ([]models.Status, error) {
+			return misskey.TimelinePublic(ctx, timeline, false, limit, "", sinceID)
+		}, keep)}, nil
+	case StreamHashtag, StreamHashtagLocal:
+		var keep func(models.Status) bool
+		if s.Stream == StreamHashtagLocal {
+			keep = isLocal
+		}
+		return []*pollSource{statusSource(func(sinceID string, limit int) ([]models.Status, error) {
+			return misskey.SearchStatusByHashtag(ctx, s.Tag, limit, "", sinceID, "")
+		}, keep)}, nil
+	case StreamList:
+		return []*pollSource{statusSource(func(sinceID string, limit int) ([]models.Status, error) {
+			return misskey.TimelineList(ctx, s.List, limit, "", sinceID)
+		}, nil)}, nil
+	case StreamDirect:
+		direct := &pollSource{
+			event: models.StreamEventTypeConversation,
+			fetch: func(sinceID string, limit int) ([]pollItem, error) {
+				notes, err := misskey.StreamDirectNotes(ctx, limit, sinceID)
+				items := make([]pollItem, 0, len(notes))
+				for _, n := range notes {
+					var payload any
+					if event, ok := misskey.StreamConversation(ctx, n); ok {
+						payload = json.RawMessage(event.Payload)
+					}
+					items = append(items, pollItem{id: n.ID, payload: payload})
+				}
+				return items, err
+			},
+		}
+		return []*pollSource{direct}, nil
+	}
+	return nil, ErrPollingUnsupported
+}
+
+// poll sends the items created since the subscription started every
+// poll interval until ctx is done. Deletions are not reported while polling.
+func (u *upstream) poll(ctx context.Context, sub *subscription, sources []*pollSource) {
+	ticker := time.NewTicker(u.hub.PollInterval)
+	defer ticker.Stop()
+	for {
+		for _, source := range sources {
+			limit := pollLimit
+			if !source.seeded {
+				// Only the latest item is needed to start the cursor.
+				limit = 1
+			}
+			items, err := source.fetch(source.cursor, limit)
+			if err != nil {
+				log.Debug().Err(err).Str("server", u.server).Str("stream", sub.Key()).Msg("Streaming poll failed")
+				continue
+			}
+			sort.Slice(items, func(i, j int) bool { return items[i].id < items[j].id })
+			if !source.seeded {
+				// The items before the subscription are not sent, without items every item fetched next is new.
+				source.seeded = true
+				if len(items) > 0 {
+					source.cursor = items[len(items)-1].id
+				}
+				continue
+			}
+			if len(items) == 0 {
+				continue
+			}
+			source.cursor = items[len(items)-1].id
+			u.mu.Lock()
+			if ctx.Err() != nil {
+				// The streaming API took over the subscription.
+				u.mu.Unlock()
+				return
+			}
+			for _, item := range items {
+				if item.payload == nil {
+					continue
+				}
+				data, err := json.Marshal(item.payload)
+				if err != nil {
+					continue
+				}
+				sub.send(models.StreamEvent{Stream: sub.Names(), Event: source.event, Payload: string(data)})
+			}
+			u.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
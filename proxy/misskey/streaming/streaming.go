@@ -2,59 +2,508 @@ package streaming
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/gizmo-ds/misstodon/internal/misstodon"
 	"github.com/gizmo-ds/misstodon/internal/utils"
 	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gizmo-ds/misstodon/proxy/misskey"
 	"github.com/gorilla/websocket"
 	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
 )
 
-func Streaming(ctx context.Context, server, token string, ch chan<- models.StreamEvent) error {
-	u := fmt.Sprintf("wss://%s/streaming?i=%s&_t=%d", server, token, time.Now().Unix())
-	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+var ErrUnauthorized = errors.New("invalid token")
+
+const (
+	// maxCapturedNotes limits the number of notes watched for deletion per connection.
+	maxCapturedNotes = 1000
+	// queueSize is the number of messages waiting to be converted per connection before messages are dropped.
+	queueSize = 256
+
+	pingInterval = 30 * time.Second
+	pongWait     = 60 * time.Second
+	writeWait    = 10 * time.Second
+
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// queuedMessage is a message read from Misskey, with the subscription it was routed to.
+type queuedMessage struct {
+	message models.MkStreamMessage
+	sub     *subscription
+	// conversation is the note of a mention sent as a conversation to the direct stream.
+	conversation *models.MkNote
+}
+
+type subscription struct {
+	Subscription
+	// ids are the connection IDs of the Misskey channels.
+	ids     []string
+	clients map[*Client]struct{}
+	// cancel stops the poller of the subscription in polling mode.
+	cancel context.CancelFunc
+}
+
+// upstream is a connection to the Misskey streaming API shared by all clients
+// using the same token, it multiplexes their stream subscriptions onto Misskey channels
+// and reconnects when the connection is lost.
+type upstream struct {
+	hub           *Hub
+	key           string
+	server, token string
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// ready is closed once the first connection attempt is done, err is its result.
+	ready chan struct{}
+	err   error
+	// polling is set while the streaming API is unreachable and the hub falls back to polling.
+	polling bool
+
+	writeMu sync.Mutex
+	ws      *websocket.Conn
+
+	// queue holds the messages read from Misskey until they are converted, the conversion makes
+	// requests to Misskey and must not block the reads.
+	queue chan queuedMessage
+
+	mu            sync.Mutex
+	clients       map[*Client]struct{}
+	subscriptions map[string]*subscription
+	channels      map[string]*subscription
+	// captured maps the ID of a note watched for deletion to the keys of the subscriptions that received it.
+	captured      map[string][]string
+	capturedOrder []string
+}
+
+func newUpstream(hub *Hub, key, server, token string) *upstream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &upstream{
+		hub:           hub,
+		key:           key,
+		server:        server,
+		token:         token,
+		ctx:           ctx,
+		cancel:        cancel,
+		ready:         make(chan struct{}),
+		queue:         make(chan queuedMessage, queueSize),
+		clients:       make(map[*Client]struct{}),
+		subscriptions: make(map[string]*subscription),
+		channels:      make(map[string]*subscription),
+		captured:      make(map[string][]string),
+	}
+}
+
+// streamingURL returns the URL of the Misskey streaming API of server, the scheme follows
+// the one of the server as in utils.JoinURL, http is ws and https is wss.
+func streamingURL(server string) string {
+	u := utils.JoinURL(server, "/streaming")
+	if rest, ok := strings.CutPrefix(u, "http://"); ok {
+		return "ws://" + rest
+	}
+	return "wss://" + strings.TrimPrefix(u, "https://")
+}
+
+// dial connects to the Misskey streaming API of server.
+// Without a token only the public channels are available.
+func dial(ctx context.Context, server, token string) (*websocket.Conn, error) {
+	u := fmt.Sprintf("%s?_t=%d", streamingURL(server), time.Now().Unix())
+	if token != "" {
+		u += "&i=" + url.QueryEscape(token)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u, nil)
 	if err != nil {
-		return err
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, ErrUnauthorized
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// backoff returns the delay before the reconnection attempt,
+// it grows exponentially up to maxBackoff and is randomized to spread reconnections.
+func backoff(attempt int) time.Duration {
+	d := maxBackoff
+	if attempt < 16 {
+		d = min(maxBackoff, minBackoff<<attempt)
+	}
+	return minBackoff/2 + rand.N(d)
+}
+
+func (u *upstream) run() {
+	go u.process()
+	ws, err := dial(u.ctx, u.server, u.token)
+	switch {
+	case err != nil && u.hub.PollingFallback && !errors.Is(err, ErrUnauthorized):
+		log.Warn().Err(err).Str("server", u.server).Msg("Streaming API is unreachable, falling back to polling")
+		u.polling = true
+		close(u.ready)
+		// The streaming API is retried while polling, and replaces the pollers once it is back.
+		if ws, err = u.reconnect(); err != nil {
+			return
+		}
+		log.Info().Str("server", u.server).Msg("Streaming API is reachable again, polling stopped")
+		u.resume(ws)
+	case err != nil:
+		u.err = err
+		close(u.ready)
+		u.stop()
+		return
+	default:
+		u.resume(ws)
+		close(u.ready)
+	}
+
+	for {
+		err = u.listen(ws)
+		if u.ctx.Err() != nil {
+			return
+		}
+		log.Debug().Err(err).Str("server", u.server).Msg("Streaming connection lost")
+		if ws, err = u.reconnect(); err != nil {
+			return
+		}
+		u.resume(ws)
+	}
+}
+
+// reconnect dials the streaming API on the backoff schedule until it succeeds,
+// it fails once ctx is done or the token is rejected, which stops the connection.
+func (u *upstream) reconnect() (*websocket.Conn, error) {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-u.ctx.Done():
+			return nil, u.ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+		ws, err := dial(u.ctx, u.server, u.token)
+		if err == nil {
+			return ws, nil
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			u.stop()
+			return nil, err
+		}
+		log.Debug().Err(err).Str("server", u.server).Int("attempt", attempt).Msg("Streaming reconnection failed")
+	}
+}
+
+// resume makes ws the current connection and restores the channels and captured notes,
+// the pollers are replaced by channels when leaving the polling mode.
+func (u *upstream) resume(ws *websocket.Conn) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.writeMu.Lock()
+	u.ws = ws
+	u.writeMu.Unlock()
+	if u.polling {
+		u.polling = false
+		for _, sub := range u.subscriptions {
+			sub.cancel()
+			sub.cancel = nil
+			for range sub.channels() {
+				id := xid.New().String()
+				sub.ids = append(sub.ids, id)
+				u.channels[id] = sub
+			}
+		}
+	}
+	for _, sub := range u.subscriptions {
+		for i, ch := range sub.channels() {
+			u.connect(sub.ids[i], ch)
+		}
+	}
+	for id := range u.captured {
+		_ = u.write(utils.Map{"type": "subNote", "body": utils.Map{"id": id}})
 	}
-	defer conn.Close()
+}
 
-	_ = conn.WriteJSON(utils.Map{
-		"type": "connect",
-		"body": utils.Map{
-			"channel": "main",
-			"id":      xid.New().String(),
-		},
+// listen reads messages from ws and sends ping frames until the connection is lost.
+func (u *upstream) listen(ws *websocket.Conn) error {
+	defer func() {
+		u.writeMu.Lock()
+		if u.ws == ws {
+			u.ws = nil
+		}
+		u.writeMu.Unlock()
+		_ = ws.Close()
+	}()
+	_ = ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(pongWait))
 	})
 
-	done := false
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
-		select {
-		case <-ctx.Done():
-			done = true
-			_ = conn.Close()
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-u.ctx.Done():
+				_ = ws.Close()
+				return
+			case <-ticker.C:
+				if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					_ = ws.Close()
+					return
+				}
+			}
 		}
 	}()
 
 	for {
 		var v models.MkStreamMessage
-		if err = conn.ReadJSON(&v); err != nil {
-			if _, ok := err.(*websocket.CloseError); ok {
-				return nil
-			}
-			if done {
-				return nil
-			}
+		if err := ws.ReadJSON(&v); err != nil {
 			return err
 		}
+		_ = ws.SetReadDeadline(time.Now().Add(pongWait))
+		u.dispatch(v)
+	}
+}
+
+// stop closes the connection and all of its clients.
+func (u *upstream) stop() {
+	u.hub.remove(u)
+	u.cancel()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for c := range u.clients {
+		c.closeDone()
+	}
+}
+
+// write sends v over the current connection, messages written while
+// disconnected are dropped and restored by resume.
+func (u *upstream) write(v any) error {
+	u.writeMu.Lock()
+	defer u.writeMu.Unlock()
+	if u.ws == nil {
+		return nil
+	}
+	_ = u.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return u.ws.WriteJSON(v)
+}
+
+func (u *upstream) connect(id string, ch channel) {
+	body := utils.Map{"channel": ch.Name, "id": id}
+	if ch.Params != nil {
+		body["params"] = ch.Params
+	}
+	_ = u.write(utils.Map{"type": "connect", "body": body})
+}
+
+func (u *upstream) subscribe(c *Client, s Subscription) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if sub, ok := u.subscriptions[s.Key()]; ok {
+		sub.clients[c] = struct{}{}
+		return nil
+	}
+	sub := &subscription{Subscription: s, clients: map[*Client]struct{}{c: {}}}
+	if u.polling {
+		sources, err := u.pollSources(s)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(u.ctx)
+		sub.cancel = cancel
+		go u.poll(ctx, sub, sources)
+	} else {
+		for _, ch := range s.channels() {
+			id := xid.New().String()
+			u.connect(id, ch)
+			sub.ids = append(sub.ids, id)
+			u.channels[id] = sub
+		}
+	}
+	u.subscriptions[s.Key()] = sub
+	return nil
+}
+
+// unsubscribe removes the client from the subscription and disconnects
+// its channels once no client is left, u.mu must be held.
+func (u *upstream) unsubscribe(c *Client, s Subscription) {
+	sub, ok := u.subscriptions[s.Key()]
+	if !ok {
+		return
+	}
+	delete(sub.clients, c)
+	if len(sub.clients) > 0 {
+		return
+	}
+	delete(u.subscriptions, s.Key())
+	if sub.cancel != nil {
+		sub.cancel()
+	}
+	for _, id := range sub.ids {
+		delete(u.channels, id)
+		_ = u.write(utils.Map{"type": "disconnect", "body": utils.Map{"id": id}})
+	}
+}
+
+// dispatch routes a message read from Misskey and queues it for process,
+// the message is dropped if process does not keep up.
+func (u *upstream) dispatch(v models.MkStreamMessage) {
+	m := queuedMessage{message: v}
+	switch v.Type {
+	case models.MkStreamMessageTypeChannel:
+		body, err := v.ParseBody()
+		if err != nil {
+			return
+		}
+		var ok bool
+		if m.sub, m.conversation, ok = u.route(body); !ok {
+			return
+		}
+	case models.MkStreamMessageTypeNoteUpdated:
+		// Deletions are queued too, so they are not sent before the notes.
+	default:
+		return
+	}
+	select {
+	case u.queue <- m:
+	default:
+		log.Debug().Str("server", u.server).Msg("Streaming messages are converted too slowly, message dropped")
+	}
+}
+
+// process converts the queued messages to events and sends them to the clients, in order, until ctx is done.
+func (u *upstream) process() {
+	for {
 		select {
-		case <-ctx.Done():
-			return nil
+		case <-u.ctx.Done():
+			return
+		case m := <-u.queue:
+			u.handle(m)
+		}
+	}
+}
+
+func (u *upstream) handle(m queuedMessage) {
+	switch m.message.Type {
+	case models.MkStreamMessageTypeChannel:
+		// The statuses are completed with requests to Misskey, u.mu is not held meanwhile.
+		ctx := misstodon.ContextWithValues(u.server, u.token)
+		var event models.StreamEvent
+		var ok bool
+		if m.conversation != nil {
+			event, ok = misskey.StreamConversation(ctx, *m.conversation)
+		} else {
+			event, ok = misskey.StreamEvent(ctx, m.message)
+		}
+		if !ok {
+			return
+		}
+		event.Stream = m.sub.Names()
+		u.mu.Lock()
+		m.sub.send(event)
+		u.mu.Unlock()
+	case models.MkStreamMessageTypeNoteUpdated:
+		body, err := m.message.ParseBody()
+		if err != nil {
+			return
+		}
+		event, ok := m.message.ToStreamEvent(u.server)
+		if !ok {
+			return
+		}
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		for _, key := range u.captured[body.ID] {
+			if sub, ok := u.subscriptions[key]; ok {
+				e := event
+				e.Stream = sub.Names()
+				sub.send(e)
+			}
+		}
+		delete(u.captured, body.ID)
+	}
+}
+
+// route returns the subscription a channel message is for, ok is false if no client wants it.
+// conversation is the note of a mention sent as a conversation to the direct stream.
+func (u *upstream) route(body models.MkStreamMessageBody) (sub *subscription, conversation *models.MkNote, ok bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	sub, ok = u.channels[body.ID]
+	if !ok {
+		return nil, nil, false
+	}
+	switch body.Type {
+	case "notification":
+		if sub.Stream != StreamUser && sub.Stream != StreamUserNotification {
+			return nil, nil, false
+		}
+	case "note":
+		var note models.MkNote
+		if err := json.Unmarshal(body.Body, &note); err != nil || !sub.accept(note) {
+			return nil, nil, false
+		}
+		u.capture(note.ID, sub.Key())
+	case "mention":
+		if sub.Stream != StreamDirect {
+			return nil, nil, false
+		}
+		var note models.MkNote
+		if err := json.Unmarshal(body.Body, &note); err != nil || note.Visibility != models.MkNoteVisibilitySpecif {
+			return nil, nil, false
+		}
+		u.capture(note.ID, sub.Key())
+		return sub, &note, true
+	default:
+		return nil, nil, false
+	}
+	return sub, nil, true
+}
+
+// send delivers the event to every client of the subscription,
+// a client that does not keep up loses the event.
+func (s *subscription) send(event models.StreamEvent) {
+	for c := range s.clients {
+		select {
+		case c.events <- event:
 		default:
+			log.Debug().Str("stream", s.Key()).Msg("Streaming client is too slow, event dropped")
 		}
-		if done {
-			return nil
+	}
+}
+
+// capture watches the note for deletion on behalf of the subscription,
+// u.mu must be held.
+func (u *upstream) capture(noteID, key string) {
+	keys, ok := u.captured[noteID]
+	if utils.Contains(keys, key) {
+		return
+	}
+	u.captured[noteID] = append(keys, key)
+	if ok {
+		return
+	}
+	_ = u.write(utils.Map{"type": "subNote", "body": utils.Map{"id": noteID}})
+	u.capturedOrder = append(u.capturedOrder, noteID)
+	for len(u.capturedOrder) > maxCapturedNotes {
+		id := u.capturedOrder[0]
+		u.capturedOrder = u.capturedOrder[1:]
+		if _, ok := u.captured[id]; ok {
+			delete(u.captured, id)
+			_ = u.write(utils.Map{"type": "unsubNote", "body": utils.Map{"id": id}})
 		}
-		ch <- v.ToStreamEvent()
 	}
 }
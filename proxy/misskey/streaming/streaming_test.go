@@ -0,0 +1,254 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 100; attempt++ {
+		d := backoff(attempt)
+		assert.GreaterOrEqual(t, d, minBackoff/2)
+		assert.Less(t, d, maxBackoff+minBackoff/2)
+	}
+	assert.Less(t, backoff(0), minBackoff+minBackoff/2)
+}
+
+func TestStreamingURL(t *testing.T) {
+	assert.Equal(t, "wss://example.com/streaming", streamingURL("example.com"))
+	assert.Equal(t, "wss://example.com/streaming", streamingURL("https://example.com"))
+	assert.Equal(t, "ws://example.com/streaming", streamingURL("http://example.com"))
+	utils.SetInsecureUpstreams([]string{"localhost:3000"})
+	defer utils.SetInsecureUpstreams(nil)
+	assert.Equal(t, "ws://localhost:3000/streaming", streamingURL("localhost:3000"))
+}
+
+func TestDispatchDoesNotBlock(t *testing.T) {
+	u := newUpstream(NewHub(), "key", "example.com", "")
+	defer u.cancel()
+	// Nothing processes the queue, the reads go on and the messages beyond the queue are dropped.
+	for i := 0; i < queueSize*2; i++ {
+		u.dispatch(models.MkStreamMessage{
+			Type: models.MkStreamMessageTypeNoteUpdated,
+			Body: json.RawMessage(`{"id":"9xyz","type":"deleted"}`),
+		})
+	}
+	assert.Len(t, u.queue, queueSize)
+}
+
+func TestHub(t *testing.T) {
+	var dials atomic.Int32
+	connected := make(chan string, 1)
+	trigger := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		dials.Add(1)
+		var msg struct {
+			Type string `json:"type"`
+			Body struct {
+				Channel string `json:"channel"`
+				ID      string `json:"id"`
+			} `json:"body"`
+		}
+		if err = conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		connected <- msg.Body.Channel + " " + msg.Body.ID
+		<-trigger
+		_ = conn.WriteJSON(utils.Map{
+			"type": "channel",
+			"body": utils.Map{
+				"id":   msg.Body.ID,
+				"type": "note",
+				"body": utils.Map{"id": "9xyz", "text": "hello", "visibility": "public"},
+			},
+		})
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	server := strings.TrimPrefix(srv.URL, "http://")
+	utils.SetInsecureUpstreams([]string{server})
+	defer utils.SetInsecureUpstreams(nil)
+
+	hub := NewHub()
+	ctx := context.Background()
+	a, err := hub.Connect(ctx, server, "token")
+	require.NoError(t, err)
+	b, err := hub.Connect(ctx, server, "token")
+	require.NoError(t, err)
+	sub := Subscription{Stream: StreamPublicLocal}
+	require.NoError(t, a.Subscribe(sub))
+	require.NoError(t, b.Subscribe(sub))
+
+	channel := <-connected
+	assert.True(t, strings.HasPrefix(channel, "localTimeline "))
+	close(trigger)
+	for _, c := range []*Client{a, b} {
+		select {
+		case event := <-c.Events():
+			assert.Equal(t, models.StreamEventTypeUpdate, event.Event)
+			assert.Equal(t, []string{"public:local"}, event.Stream)
+			assert.Contains(t, event.Payload, `"id":"9xyz"`)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event received")
+		}
+	}
+	assert.EqualValues(t, 1, dials.Load())
+
+	a.Close()
+	b.Close()
+	<-b.Done()
+	hub.mu.Lock()
+	assert.Empty(t, hub.upstreams)
+	hub.mu.Unlock()
+}
+
+func TestPollingFallback(t *testing.T) {
+	var accept atomic.Bool
+	connected := make(chan string, 8)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/streaming":
+			if !accept.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			for {
+				var msg struct {
+					Body struct {
+						Channel string `json:"channel"`
+					} `json:"body"`
+				}
+				if err = conn.ReadJSON(&msg); err != nil {
+					return
+				}
+				connected <- msg.Body.Channel
+			}
+		case "/api/notes/timeline":
+			var body struct {
+				SinceID string `json:"sinceId"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			id := "1"
+			if body.SinceID != "" {
+				id = "2"
+			}
+			_ = json.NewEncoder(w).Encode([]utils.Map{{"id": id, "text": "hello", "visibility": "public"}})
+		case "/api/i/notifications":
+			_, _ = w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer srv.Close()
+	server := strings.TrimPrefix(srv.URL, "http://")
+	utils.SetInsecureUpstreams([]string{server})
+	defer utils.SetInsecureUpstreams(nil)
+
+	hub := NewHub()
+	hub.PollingFallback = true
+	hub.PollInterval = 50 * time.Millisecond
+	c, err := hub.Connect(context.Background(), server, "token")
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Subscribe(Subscription{Stream: StreamUser}))
+	_, err = c.upstream.pollSources(Subscription{Stream: "unknown"})
+	assert.ErrorIs(t, err, ErrPollingUnsupported)
+
+	select {
+	case event := <-c.Events():
+		assert.Equal(t, models.StreamEventTypeUpdate, event.Event)
+		assert.Contains(t, event.Payload, `"id":"2"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event polled")
+	}
+
+	// The streaming API replaces the pollers once it is reachable again.
+	accept.Store(true)
+	var channels []string
+	for len(channels) < 2 {
+		select {
+		case channel := <-connected:
+			channels = append(channels, channel)
+		case <-time.After(10 * time.Second):
+			t.Fatal("the streaming API was not retried")
+		}
+	}
+	assert.ElementsMatch(t, []string{"main", "homeTimeline"}, channels)
+	c.upstream.mu.Lock()
+	assert.False(t, c.upstream.polling)
+	for _, sub := range c.upstream.subscriptions {
+		assert.Nil(t, sub.cancel)
+		assert.Len(t, sub.ids, 2)
+	}
+	c.upstream.mu.Unlock()
+}
+
+func TestPollingEmptySource(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/notes/local-timeline":
+			// The timeline is empty when the stream starts, the next note is new.
+			if calls.Add(1) == 1 {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_ = json.NewEncoder(w).Encode([]utils.Map{{"id": "3", "text": "hello", "visibility": "public"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer srv.Close()
+	server := strings.TrimPrefix(srv.URL, "http://")
+	utils.SetInsecureUpstreams([]string{server})
+	defer utils.SetInsecureUpstreams(nil)
+
+	hub := NewHub()
+	hub.PollingFallback = true
+	hub.PollInterval = 50 * time.Millisecond
+	c, err := hub.Connect(context.Background(), server, "")
+	require.NoError(t, err)
+	defer c.Close()
+	require.NoError(t, c.Subscribe(Subscription{Stream: StreamPublicLocal}))
+
+	select {
+	case event := <-c.Events():
+		assert.Equal(t, models.StreamEventTypeUpdate, event.Event)
+		assert.Equal(t, []string{"public:local"}, event.Stream)
+		assert.Contains(t, event.Payload, `"id":"3"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event polled")
+	}
+}
@@ -0,0 +1,114 @@
+package streaming
+
+import (
+	"errors"
+
+	"github.com/gizmo-ds/misstodon/internal/utils"
+	"github.com/gizmo-ds/misstodon/models"
+)
+
+var (
+	ErrUnknownStream = errors.New("unknown stream type")
+	ErrMissingTag    = errors.New("no tag for hashtag stream")
+	ErrMissingList   = errors.New("no list for list stream")
+)
+
+const (
+	StreamUser             = "user"
+	StreamUserNotification = "user:notification"
+	StreamPublic           = "public"
+	StreamPublicLocal      = "public:local"
+	StreamPublicRemote     = "public:remote"
+	StreamHashtag          = "hashtag"
+	StreamHashtagLocal     = "hashtag:local"
+	StreamList             = "list"
+	StreamDirect           = "direct"
+)
+
+// Subscription is a Mastodon stream subscription,
+// Tag is used by hashtag streams and List by list streams.
+type Subscription struct {
+	Stream string
+	Tag    string
+	List   string
+}
+
+// Validate checks that the stream is supported and has its required parameter.
+func (s Subscription) Validate() error {
+	switch s.Stream {
+	case StreamUser, StreamUserNotification, StreamPublic, StreamPublicLocal, StreamPublicRemote, StreamDirect:
+	case StreamHashtag, StreamHashtagLocal:
+		if s.Tag == "" {
+			return ErrMissingTag
+		}
+	case StreamList:
+		if s.List == "" {
+			return ErrMissingList
+		}
+	default:
+		return ErrUnknownStream
+	}
+	return nil
+}
+
+// Names returns the value of the stream field of events sent for the subscription.
+func (s Subscription) Names() []string {
+	switch s.Stream {
+	case StreamHashtag, StreamHashtagLocal:
+		return []string{s.Stream, s.Tag}
+	case StreamList:
+		return []string{s.Stream, s.List}
+	}
+	return []string{s.Stream}
+}
+
+// Key identifies the subscription, subscribing twice with the same key is a no-op.
+func (s Subscription) Key() string {
+	key := s.Stream
+	for _, name := range s.Names()[1:] {
+		key += ":" + name
+	}
+	return key
+}
+
+type channel struct {
+	Name   string
+	Params utils.Map
+}
+
+// channels returns the Misskey channels that make up the subscription.
+func (s Subscription) channels() []channel {
+	switch s.Stream {
+	case StreamUser:
+		return []channel{{Name: "main"}, {Name: "homeTimeline"}}
+	case StreamUserNotification, StreamDirect:
+		return []channel{{Name: "main"}}
+	case StreamPublic, StreamPublicRemote:
+		return []channel{{Name: "globalTimeline"}}
+	case StreamPublicLocal:
+		return []channel{{Name: "localTimeline"}}
+	case StreamHashtag, StreamHashtagLocal:
+		return []channel{{Name: "hashtag", Params: utils.Map{"q": [][]string{{s.Tag}}}}}
+	case StreamList:
+		if antennaID, ok := models.AntennaID(s.List); ok {
+			return []channel{{Name: "antenna", Params: utils.Map{"antennaId": antennaID}}}
+		}
+		if channelID, ok := models.ChannelID(s.List); ok {
+			return []channel{{Name: "channel", Params: utils.Map{"channelId": channelID}}}
+		}
+		return []channel{{Name: "userList", Params: utils.Map{"listId": s.List}}}
+	}
+	return nil
+}
+
+// accept reports whether a note received on one of the subscription channels belongs to the stream.
+func (s Subscription) accept(note models.MkNote) bool {
+	local := note.User == nil || note.User.Host == nil
+	switch s.Stream {
+	case StreamPublicRemote:
+		return !local
+	case StreamHashtagLocal:
+		return local
+	}
+	return true
+}
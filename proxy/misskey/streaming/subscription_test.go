@@ -0,0 +1,30 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscription(t *testing.T) {
+	s := Subscription{Stream: StreamHashtag, Tag: "misskey"}
+	assert.NoError(t, s.Validate())
+	assert.Equal(t, []string{"hashtag", "misskey"}, s.Names())
+	assert.Equal(t, "hashtag:misskey", s.Key())
+	assert.Equal(t, "hashtag", s.channels()[0].Name)
+
+	s = Subscription{Stream: StreamPublicLocal}
+	assert.NoError(t, s.Validate())
+	assert.Equal(t, []string{"public:local"}, s.Names())
+	assert.Equal(t, "localTimeline", s.channels()[0].Name)
+
+	assert.Len(t, Subscription{Stream: StreamUser}.channels(), 2)
+	assert.Equal(t, "userList", Subscription{Stream: StreamList, List: "9abc"}.channels()[0].Name)
+	assert.Equal(t, channel{Name: "antenna", Params: map[string]any{"antennaId": "9abc"}},
+		Subscription{Stream: StreamList, List: "antenna_9abc"}.channels()[0])
+	assert.Equal(t, channel{Name: "channel", Params: map[string]any{"channelId": "9abc"}},
+		Subscription{Stream: StreamList, List: "channel_9abc"}.channels()[0])
+	assert.ErrorIs(t, Subscription{Stream: StreamList}.Validate(), ErrMissingList)
+	assert.ErrorIs(t, Subscription{Stream: StreamHashtagLocal}.Validate(), ErrMissingTag)
+	assert.ErrorIs(t, Subscription{Stream: "public:bubble"}.Validate(), ErrUnknownStream)
+}
@@ -23,7 +23,7 @@ func Suggestions(ctx Context, limit int) ([]models.Account, error) {
 	}
 	var accounts []models.Account
 	for _, u := range result {
-		if a, err := u.ToAccount(ctx.ProxyServer()); err == nil {
+		if a, err := userToAccount(ctx, &u); err == nil {
 			accounts = append(accounts, a)
 		}
 	}
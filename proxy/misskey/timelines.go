@@ -4,7 +4,6 @@ import (
 	"github.com/gizmo-ds/misstodon/internal/utils"
 	"github.com/gizmo-ds/misstodon/models"
 	"github.com/pkg/errors"
-	"github.com/samber/lo"
 )
 
 func TimelinePublic(ctx Context,
@@ -38,7 +37,7 @@ func TimelinePublic(ctx Context,
 	if err != nil {
 		return nil, err
 	}
-	list := lo.Map(result, func(n models.MkNote, _ int) models.Status { return n.ToStatus(ctx.ProxyServer()) })
+	list := notesToStatuses(ctx, result)
 	return list, nil
 }
 
@@ -59,6 +58,6 @@ func TimelineHome(ctx Context,
 	if err != nil {
 		return nil, err
 	}
-	list := lo.Map(result, func(n models.MkNote, _ int) models.Status { return n.ToStatus(ctx.ProxyServer()) })
+	list := notesToStatuses(ctx, result)
 	return list, nil
 }
@@ -2,11 +2,14 @@ package misskey
 
 import (
 	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gizmo-ds/misstodon/internal/utils"
 	"github.com/gizmo-ds/misstodon/models"
+	"github.com/pkg/errors"
 )
 
 func TrendsTags(ctx Context, limit, offset int) ([]models.Tag, error) {
@@ -44,7 +47,6 @@ func TrendsTags(ctx Context, limit, offset int) ([]models.Tag, error) {
 }
 
 func TrendsStatus(ctx Context, limit, offset int) ([]models.Status, error) {
-	var statuses []models.Status
 	var result []models.MkNote
 	_, err := client.R().
 		SetBody(makeBody(ctx, utils.Map{"limit": limit})).
@@ -53,8 +55,66 @@ func TrendsStatus(ctx Context, limit, offset int) ([]models.Status, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, note := range result {
-		statuses = append(statuses, note.ToStatus(ctx.ProxyServer()))
+	return notesToStatuses(ctx, result), nil
+}
+
+// trendsLinksNotes is how many featured notes the links are counted from, the most Misskey returns.
+const trendsLinksNotes = 100
+
+// TrendsLinks returns the links of the featured notes with a preview, the ones shared by the most users first.
+func TrendsLinks(ctx Context, limit, offset int) ([]models.TrendsLink, error) {
+	var result []models.MkNote
+	resp, err := client.R().
+		SetBody(makeBody(ctx, utils.Map{"limit": trendsLinksNotes})).
+		SetResult(&result).
+		Post(utils.JoinURL(ctx.ProxyServer(), "/api/notes/featured"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = isucceed(resp, http.StatusOK); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var links []string
+	uses := make(map[string]int)
+	accounts := make(map[string][]string)
+	for _, n := range result {
+		link := n.LinkUrl()
+		if link == "" {
+			continue
+		}
+		if uses[link] == 0 {
+			links = append(links, link)
+		}
+		uses[link]++
+		if !utils.Contains(accounts[link], n.UserId) {
+			accounts[link] = append(accounts[link], n.UserId)
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if len(accounts[links[i]]) != len(accounts[links[j]]) {
+			return len(accounts[links[i]]) > len(accounts[links[j]])
+		}
+		return uses[links[i]] > uses[links[j]]
+	})
+	cards := linkCards(ctx.ProxyServer(), links)
+	var trends []models.TrendsLink
+	for _, link := range links {
+		card := cards[link]
+		if card == nil {
+			continue
+		}
+		trends = append(trends, models.TrendsLink{
+			Card: *card,
+			History: []models.TrendsHistory{{
+				Day:      fmt.Sprint(time.Now().Truncate(24 * time.Hour).Unix()),
+				Uses:     strconv.Itoa(uses[link]),
+				Accounts: strconv.Itoa(len(accounts[link])),
+			}},
+		})
+	}
+	trends = trends[min(max(offset, 0), len(trends)):]
+	if len(trends) > limit {
+		trends = trends[:limit]
 	}
-	return statuses, nil
+	return trends, nil
 }
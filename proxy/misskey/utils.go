@@ -56,6 +56,14 @@ func isucceed(resp *resty.Response, statusCode int, codes ...string) error {
 	return errors.New(result.Error.Msg)
 }
 
+// isucceedNotFound is isucceed that reports the error codes of a missing object as ErrNotFound.
+func isucceedNotFound(resp *resty.Response, codes ...string) error {
+	if resp.IsError() && isucceed(resp, http.StatusOK, codes...) == nil {
+		return ErrNotFound
+	}
+	return isucceed(resp, http.StatusOK)
+}
+
 func makeBody(ctx Context, m utils.Map) utils.Map {
 	r := utils.Map{}
 	token := ctx.Token()